@@ -0,0 +1,181 @@
+// Package apierror defines the error every endpoint responds with. Handlers
+// attach an *Error to the gin context and the error middleware writes it as
+//
+//	{"error": {"code": "not_found", "message": "Movie not found", "request_id": "..."}}
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Machine-readable error codes.
+const (
+	CodeInvalidInput     = "invalid_input"
+	CodeValidationFailed = "validation_failed"
+	CodeImmutableField   = "immutable_field"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodePrecondition     = "precondition_failed"
+	CodeInternal         = "internal_error"
+	CodeTimeout          = "timeout"
+	CodeCancelled        = "request_cancelled"
+)
+
+// StatusClientClosedRequest is answered, to no one, when the client went away
+// before the response was ready.
+const StatusClientClosedRequest = 499
+
+// Error is an error response. Err, the underlying cause, is logged but never
+// sent to the client.
+type Error struct {
+	Status  int          `json:"-"`
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+	Err     error        `json:"-"`
+}
+
+// FieldError tells which field of the request body failed which rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Response is the body of every error response.
+type Response struct {
+	Error ResponseBody `json:"error"`
+}
+
+type ResponseBody struct {
+	*Error
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap sets the underlying cause of the error.
+func (e *Error) Wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidInput, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// PreconditionFailed returns a 412 error, for writes made against a version
+// of the document that is no longer the current one.
+func PreconditionFailed(message string) *Error {
+	return New(http.StatusPreconditionFailed, CodePrecondition, message)
+}
+
+// Internal returns a 500 error caused by err, or a 504 when err is a deadline
+// exceeded and a 499 when the request was cancelled.
+func Internal(message string, err error) *Error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(http.StatusGatewayTimeout, CodeTimeout, message).Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return New(StatusClientClosedRequest, CodeCancelled, message).Wrap(err)
+	default:
+		return New(http.StatusInternalServerError, CodeInternal, message).Wrap(err)
+	}
+}
+
+// Validation returns a 400 error listing the field errors of err when it is
+// a validator.ValidationErrors, or carrying err as cause otherwise.
+func Validation(message string, err error) *Error {
+	apiErr := New(http.StatusBadRequest, CodeValidationFailed, message).Wrap(err)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fieldErr := range validationErrors {
+			apiErr.Fields = append(apiErr.Fields, FieldError{
+				Field:   fieldPath(fieldErr.Namespace()),
+				Rule:    fieldErr.Tag(),
+				Message: ruleMessage(fieldErr),
+			})
+		}
+	}
+
+	return apiErr
+}
+
+// From returns err as an *Error, an internal error when it is not one.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return Internal("Internal server error", err)
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+// fieldPath drops the struct name the validator namespace starts with, as
+// in "User.email".
+func fieldPath(namespace string) string {
+	if _, path, found := strings.Cut(namespace, "."); found {
+		return path
+	}
+	return namespace
+}
+
+func ruleMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "min":
+		return "must be at least " + fieldErr.Param()
+	case "max":
+		return "must be at most " + fieldErr.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	default:
+		return "failed the " + fieldErr.Tag() + " rule"
+	}
+}
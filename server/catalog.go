@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"server/controllers"
+	"server/models"
+	"server/utils"
+)
+
+const catalogUsage = "usage: server import movie|tv_show FILE [csv|ndjson]\n" +
+	"       server export FILE [json|ndjson]\n" +
+	"       server restore FILE [merge|replace] [json|ndjson]"
+
+// runCatalogCommand runs the subcommands moving titles in and out of the
+// catalog:
+//
+//	server import movie|tv_show FILE [csv|ndjson]          imports titles in bulk
+//	server export FILE [json|ndjson]                       writes a catalog archive
+//	server restore FILE [merge|replace] [json|ndjson]      restores a catalog archive
+//
+// The format defaults to the one the file extension names.
+func runCatalogCommand(ctx context.Context, app *controllers.App, command string, args []string) error {
+	switch command {
+	case "import":
+		return runImportCommand(ctx, app, args)
+	case "export":
+		return runExportCommand(ctx, app, args)
+	case "restore":
+		return runRestoreCommand(ctx, app, args)
+	default:
+		return errors.New(catalogUsage)
+	}
+}
+
+// runImportCommand upserts the titles of FILE and prints the rows that
+// failed.
+func runImportCommand(ctx context.Context, app *controllers.App, args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New(catalogUsage)
+	}
+	contentType, path := args[0], args[1]
+
+	format := formatOf(path)
+	if len(args) == 3 {
+		format = args[2]
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report, err := app.ImportCatalog(ctx, contentType, file, format, "")
+	if err != nil {
+		return err
+	}
+
+	if err := printImportReport("", report); err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d rows failed", report.Failed)
+	}
+	return nil
+}
+
+// runExportCommand writes the catalog archive to FILE. Logs go to stdout,
+// so the archive cannot.
+func runExportCommand(ctx context.Context, app *controllers.App, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New(catalogUsage)
+	}
+	path, format := args[0], formatOf(args[0])
+	if len(args) == 2 {
+		format = args[1]
+	}
+	if format != controllers.ArchiveJSON && format != controllers.ArchiveNDJSON {
+		return errors.New(catalogUsage)
+	}
+
+	archive, err := app.ExportCatalogArchive(ctx)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := controllers.WriteCatalogArchive(file, archive, format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported %d movies, %d TV shows, %d genres and %d rankings to %s\n",
+		len(archive.Movies), len(archive.TVShows), len(archive.Genres), len(archive.Rankings), path)
+	return nil
+}
+
+// runRestoreCommand restores the archive of FILE, merged into the catalog
+// unless the mode is replace, and prints the titles that failed.
+func runRestoreCommand(ctx context.Context, app *controllers.App, args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return errors.New(catalogUsage)
+	}
+	path, mode, format := args[0], models.RestoreMerge, formatOf(args[0])
+	if len(args) > 1 {
+		mode = args[1]
+	}
+	if len(args) > 2 {
+		format = args[2]
+	}
+	if mode != models.RestoreMerge && mode != models.RestoreReplace {
+		return errors.New(catalogUsage)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	archive, err := controllers.ReadCatalogArchive(file, format)
+	if err != nil {
+		return err
+	}
+
+	report, err := app.RestoreCatalogArchive(ctx, archive, mode, "")
+	if printErr := printImportReport("movies", report.Movies); printErr != nil {
+		return printErr
+	}
+	if printErr := printImportReport("TV shows", report.TVShows); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d genres and %d rankings\n", report.Genres, report.Rankings)
+
+	if failed := report.Movies.Failed + report.TVShows.Failed; failed > 0 {
+		return fmt.Errorf("%d titles failed", failed)
+	}
+	return nil
+}
+
+// printImportReport prints the failed rows of the report and its totals,
+// prefixed with what the rows are when set.
+func printImportReport(what string, report models.ImportReport) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if report.Failed > 0 {
+		fmt.Fprintln(w, "ROW\tIMDB ID\tREASON")
+		for _, row := range report.Rows {
+			if row.Status == models.ImportFailed {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", row.Row, row.ImdbID, row.Reason)
+			}
+		}
+	}
+	if what != "" {
+		fmt.Fprintf(w, "%s: ", strings.ToUpper(what[:1])+what[1:])
+	}
+	fmt.Fprintf(w, "created %d, updated %d, failed %d\n", report.Created, report.Updated, report.Failed)
+	return w.Flush()
+}
+
+// formatOf returns the format the extension of path names, .jsonl counting
+// as NDJSON.
+func formatOf(path string) string {
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	if format == "jsonl" {
+		return utils.FormatNDJSON
+	}
+	return format
+}
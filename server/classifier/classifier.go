@@ -0,0 +1,106 @@
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"server/config"
+	"server/metrics"
+	"server/models"
+)
+
+// Classifier picks the ranking that best describes an admin review.
+type Classifier interface {
+	// Classify returns the ranking name chosen for the review and its value,
+	// 0 when the name matches none of the rankings.
+	Classify(ctx context.Context, review string, rankings []models.Ranking) (string, int, error)
+	// Check verifies the provider is reachable and accepts the credentials,
+	// without classifying anything.
+	Check(ctx context.Context) error
+}
+
+// New returns the classifier of the configured provider, instrumented.
+func New(cfg config.ClassifierConfig) Classifier {
+	if cfg.UseHuggingFace {
+		return &instrumentedClassifier{
+			Classifier: NewHuggingFace(cfg.HuggingFaceToken, cfg.HuggingFaceModel, cfg.BasePromptTemplate),
+			provider:   "huggingface",
+		}
+	}
+	return &instrumentedClassifier{
+		Classifier: NewOpenAI(cfg.OpenAIAPIKey, cfg.BasePromptTemplate),
+		provider:   "openai",
+	}
+}
+
+// instrumentedClassifier records the count, latency and failures of the
+// classifications of the classifier it wraps.
+type instrumentedClassifier struct {
+	Classifier
+	provider string
+}
+
+func (i *instrumentedClassifier) Classify(ctx context.Context, review string,
+	rankings []models.Ranking) (string, int, error) {
+
+	start := time.Now()
+	name, value, err := i.Classifier.Classify(ctx, review, rankings)
+	metrics.ObserveClassification(i.provider, start, err)
+
+	return name, value, err
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+// buildPrompt fills the template's {rankings} placeholder with the names of
+// the ranked rankings and appends the review.
+func buildPrompt(template, review string, rankings []models.Ranking) string {
+	sentiment := ""
+	for _, ranking := range rankings {
+		if ranking.RankingValue != 999 {
+			sentiment = sentiment + ranking.RankingName + ","
+		}
+	}
+	sentiment = strings.Trim(sentiment, ",")
+
+	return strings.Replace(template, "{rankings}", sentiment, 1) + review
+}
+
+// checkEndpoint sends an authenticated GET to url and fails unless the
+// provider answers 200.
+func checkEndpoint(ctx context.Context, client *http.Client, url, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "Error closing response body", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("provider answered %s", resp.Status)
+	}
+	return nil
+}
+
+func rankingValue(rankings []models.Ranking, rankingName string) int {
+	for _, ranking := range rankings {
+		if ranking.RankingName == rankingName {
+			return ranking.RankingValue
+		}
+	}
+	return 0
+}
@@ -0,0 +1,113 @@
+package classifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"server/models"
+)
+
+type huggingFaceClassifier struct {
+	token          string
+	model          string
+	promptTemplate string
+	client         *http.Client
+}
+
+func NewHuggingFace(token, model, promptTemplate string) Classifier {
+	return &huggingFaceClassifier{
+		token:          token,
+		model:          model,
+		promptTemplate: promptTemplate,
+		client:         &http.Client{},
+	}
+}
+
+func (h *huggingFaceClassifier) Classify(ctx context.Context, review string,
+	rankings []models.Ranking) (string, int, error) {
+
+	if h.token == "" {
+		return "", 0, errors.New("HUGGING_FACE_HUB_TOKEN not set")
+	}
+	if h.model == "" {
+		return "", 0, errors.New("HF_MODEL not set")
+	}
+
+	response, err := h.call(ctx, buildPrompt(h.promptTemplate, review, rankings))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return response, rankingValue(rankings, response), nil
+}
+
+func (h *huggingFaceClassifier) Check(ctx context.Context) error {
+	url := fmt.Sprintf("https://huggingface.co/api/models/%s", h.model)
+	return checkEndpoint(ctx, h.client, url, h.token)
+}
+
+func (h *huggingFaceClassifier) call(ctx context.Context, prompt string) (string, error) {
+	url := fmt.Sprintf("https://api-inference.huggingface.co/models/%s", h.model)
+
+	payload := map[string]interface{}{
+		"inputs": prompt,
+		"parameters": map[string]interface{}{
+			"max_new_tokens": 50,
+			"temperature":    0.1,
+		},
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+h.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			slog.WarnContext(ctx, "Error closing response body", "error", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("HuggingFace API error: %s - %s", resp.Status, string(body))
+	}
+
+	var result []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	if len(result) == 0 {
+		return "", errors.New("empty response from HuggingFace")
+	}
+
+	generatedText, ok := result[0]["generated_text"].(string)
+	if !ok {
+		return "", errors.New("invalid response format from HuggingFace")
+	}
+
+	generatedText = strings.TrimPrefix(generatedText, prompt)
+	generatedText = strings.TrimSpace(generatedText)
+
+	return generatedText, nil
+}
@@ -0,0 +1,45 @@
+package classifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"server/models"
+
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+type openAIClassifier struct {
+	apiKey         string
+	promptTemplate string
+	client         *http.Client
+}
+
+func NewOpenAI(apiKey, promptTemplate string) Classifier {
+	return &openAIClassifier{apiKey: apiKey, promptTemplate: promptTemplate, client: &http.Client{}}
+}
+
+func (o *openAIClassifier) Classify(ctx context.Context, review string,
+	rankings []models.Ranking) (string, int, error) {
+
+	if o.apiKey == "" {
+		return "", 0, errors.New("could not read OpenAI Key")
+	}
+
+	llm, err := openai.New(openai.WithToken(o.apiKey))
+	if err != nil {
+		return "", 0, err
+	}
+
+	response, err := llm.Call(ctx, buildPrompt(o.promptTemplate, review, rankings))
+	if err != nil {
+		return "", 0, err
+	}
+
+	return response, rankingValue(rankings, response), nil
+}
+
+func (o *openAIClassifier) Check(ctx context.Context) error {
+	return checkEndpoint(ctx, o.client, "https://api.openai.com/v1/models", o.apiKey)
+}
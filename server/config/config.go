@@ -0,0 +1,333 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MaxRecommendationLimit caps every configured and requested page size.
+	MaxRecommendationLimit int64 = 50
+
+	minSecretLength = 32
+)
+
+// Config holds the settings the application is built from.
+type Config struct {
+	Server ServerConfig `yaml:"server"`
+	// LogLevel is the minimum level logged: debug, info, warn or error.
+	LogLevel slog.Level `yaml:"log_level"`
+
+	MongoURI     string `yaml:"mongodb_uri"`
+	DatabaseName string `yaml:"database_name"`
+	// MongoConnectRetries is how many more times the startup ping is tried
+	// after the first failure, MongoRetryInterval apart.
+	MongoConnectRetries int           `yaml:"mongodb_connect_retries"`
+	MongoRetryInterval  time.Duration `yaml:"mongodb_retry_interval"`
+	// MongoOperationTimeout bounds the database work of a request.
+	MongoOperationTimeout time.Duration `yaml:"mongodb_operation_timeout"`
+	// MigrateOnStartup applies the pending migrations before serving. When
+	// off, they are applied with the migrate command.
+	MigrateOnStartup bool `yaml:"migrate_on_startup"`
+
+	SecretKey        string `yaml:"secret_key"`
+	SecretRefreshKey string `yaml:"secret_refresh_key"`
+
+	Classifier      ClassifierConfig     `yaml:"classifier"`
+	Recommendations RecommendationConfig `yaml:"recommendations"`
+	Readiness       ReadinessConfig      `yaml:"readiness"`
+	Trash           TrashConfig          `yaml:"trash"`
+}
+
+// ServerConfig configures the HTTP server. ShutdownTimeout bounds how long
+// in-flight requests and background workers are given to finish on exit.
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// ClassifierConfig selects and configures the provider that turns admin
+// reviews into rankings. Timeout bounds each call to the provider.
+type ClassifierConfig struct {
+	UseHuggingFace     bool          `yaml:"use_hugging_face"`
+	BasePromptTemplate string        `yaml:"base_prompt_template"`
+	HuggingFaceToken   string        `yaml:"hugging_face_token"`
+	HuggingFaceModel   string        `yaml:"hugging_face_model"`
+	OpenAIAPIKey       string        `yaml:"openai_api_key"`
+	Timeout            time.Duration `yaml:"timeout"`
+}
+
+// RecommendationConfig holds the default page sizes and the scoring weights
+// of the recommendation, similar titles and onboarding endpoints.
+type RecommendationConfig struct {
+	MovieLimit              int64                 `yaml:"movie_limit"`
+	TVShowLimit             int64                 `yaml:"tv_show_limit"`
+	SimilarTitlesLimit      int64                 `yaml:"similar_titles_limit"`
+	OnboardingSampleSize    int64                 `yaml:"onboarding_sample_size"`
+	TrendingRefreshInterval time.Duration         `yaml:"trending_refresh_interval"`
+	TrendingRefreshTimeout  time.Duration         `yaml:"trending_refresh_timeout"`
+	Weights                 RecommendationWeights `yaml:"weights"`
+}
+
+type RecommendationWeights struct {
+	Genre    float64 `yaml:"genre"`
+	Ranking  float64 `yaml:"ranking"`
+	Recency  float64 `yaml:"recency"`
+	Trending float64 `yaml:"trending"`
+}
+
+// ReadinessConfig configures /readyz. Every dependency check is given
+// Timeout; the classifier provider is only checked when CheckClassifier is
+// set, since each check is a call to a paid API.
+type ReadinessConfig struct {
+	Timeout         time.Duration `yaml:"timeout"`
+	CheckClassifier bool          `yaml:"check_classifier"`
+}
+
+// TrashConfig configures the purge of deleted movies and TV shows, which
+// are kept Retention long and looked for every PurgeInterval.
+type TrashConfig struct {
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+// Default returns the configuration used for every setting that is not set.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    2 * time.Minute,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		MongoConnectRetries:   5,
+		MongoRetryInterval:    2 * time.Second,
+		MongoOperationTimeout: 10 * time.Second,
+		MigrateOnStartup:      true,
+		Classifier: ClassifierConfig{
+			UseHuggingFace: true,
+			Timeout:        30 * time.Second,
+		},
+		Recommendations: RecommendationConfig{
+			MovieLimit:              5,
+			TVShowLimit:             5,
+			SimilarTitlesLimit:      5,
+			OnboardingSampleSize:    5,
+			TrendingRefreshInterval: 15 * time.Minute,
+			TrendingRefreshTimeout:  2 * time.Minute,
+			Weights: RecommendationWeights{
+				Genre:    3,
+				Ranking:  2,
+				Recency:  1,
+				Trending: 2,
+			},
+		},
+		Readiness: ReadinessConfig{Timeout: 5 * time.Second},
+		Trash: TrashConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+	}
+}
+
+// Load builds the configuration once at startup. Defaults are overridden by
+// the YAML file named by CONFIG_FILE, if any, and then by the environment,
+// after loading the .env file when there is one. Every problem found is
+// reported in the returned error, not only the first one.
+func Load() (*Config, error) {
+	if err := godotenv.Load(".env"); err != nil {
+		slog.Warn(".env file not found")
+	}
+
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	env := &envReader{}
+	env.level("LOG_LEVEL", &cfg.LogLevel)
+	env.string("SERVER_ADDR", &cfg.Server.Addr)
+	env.duration("SERVER_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	env.string("MONGODB_URI", &cfg.MongoURI)
+	env.string("DATABASE_NAME", &cfg.DatabaseName)
+	env.int("MONGODB_CONNECT_RETRIES", &cfg.MongoConnectRetries)
+	env.duration("MONGODB_RETRY_INTERVAL", &cfg.MongoRetryInterval)
+	env.duration("MONGODB_OPERATION_TIMEOUT", &cfg.MongoOperationTimeout)
+	env.bool("MIGRATE_ON_STARTUP", &cfg.MigrateOnStartup)
+	env.string("SECRET_KEY", &cfg.SecretKey)
+	env.string("SECRET_REFRESH_KEY", &cfg.SecretRefreshKey)
+
+	env.bool("USE_HUGGING_FAME", &cfg.Classifier.UseHuggingFace)
+	env.string("BASE_PROMPT_TEMPLATE", &cfg.Classifier.BasePromptTemplate)
+	env.string("HUGGING_FACE_HUB_TOKEN", &cfg.Classifier.HuggingFaceToken)
+	env.string("HF_MODEL", &cfg.Classifier.HuggingFaceModel)
+	env.string("OPENAI_API_KEY", &cfg.Classifier.OpenAIAPIKey)
+	env.duration("CLASSIFIER_TIMEOUT", &cfg.Classifier.Timeout)
+
+	recommendations := &cfg.Recommendations
+	env.int64("RECOMMENDED_MOVIE_LIMIT", &recommendations.MovieLimit)
+	env.int64("RECOMMENDED_TV_SHOW_LIMIT", &recommendations.TVShowLimit)
+	env.int64("SIMILAR_TITLES_LIMIT", &recommendations.SimilarTitlesLimit)
+	env.int64("ONBOARDING_SAMPLE_SIZE", &recommendations.OnboardingSampleSize)
+	env.duration("TRENDING_REFRESH_INTERVAL", &recommendations.TrendingRefreshInterval)
+	env.duration("TRENDING_REFRESH_TIMEOUT", &recommendations.TrendingRefreshTimeout)
+	env.float("RECOMMENDATION_GENRE_WEIGHT", &recommendations.Weights.Genre)
+	env.float("RECOMMENDATION_RANKING_WEIGHT", &recommendations.Weights.Ranking)
+	env.float("RECOMMENDATION_RECENCY_WEIGHT", &recommendations.Weights.Recency)
+	env.float("RECOMMENDATION_TRENDING_WEIGHT", &recommendations.Weights.Trending)
+
+	env.duration("READINESS_TIMEOUT", &cfg.Readiness.Timeout)
+	env.bool("READINESS_CHECK_CLASSIFIER", &cfg.Readiness.CheckClassifier)
+
+	env.duration("TRASH_RETENTION", &cfg.Trash.Retention)
+	env.duration("TRASH_PURGE_INTERVAL", &cfg.Trash.PurgeInterval)
+
+	if err := errors.Join(env.problems, cfg.Validate()); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the required values are present and every value is in
+// range, returning all the problems joined.
+func (c *Config) Validate() error {
+	var problems []error
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	if c.Server.Addr == "" {
+		problem("SERVER_ADDR is required")
+	}
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			problem("%s must be positive", timeout.key)
+		}
+	}
+
+	if c.MongoURI == "" {
+		problem("MONGODB_URI is required")
+	} else if !strings.HasPrefix(c.MongoURI, "mongodb://") && !strings.HasPrefix(c.MongoURI, "mongodb+srv://") {
+		problem("MONGODB_URI must start with mongodb:// or mongodb+srv://")
+	}
+	if c.DatabaseName == "" {
+		problem("DATABASE_NAME is required")
+	}
+	if c.MongoConnectRetries < 0 {
+		problem("MONGODB_CONNECT_RETRIES must not be negative")
+	}
+	if c.MongoRetryInterval <= 0 {
+		problem("MONGODB_RETRY_INTERVAL must be positive")
+	}
+	if c.MongoOperationTimeout <= 0 {
+		problem("MONGODB_OPERATION_TIMEOUT must be positive")
+	}
+
+	if len(c.SecretKey) < minSecretLength {
+		problem("SECRET_KEY must be at least %d characters long", minSecretLength)
+	}
+	if len(c.SecretRefreshKey) < minSecretLength {
+		problem("SECRET_REFRESH_KEY must be at least %d characters long", minSecretLength)
+	}
+	if c.SecretKey != "" && c.SecretKey == c.SecretRefreshKey {
+		problem("SECRET_KEY and SECRET_REFRESH_KEY must differ")
+	}
+
+	if !strings.Contains(c.Classifier.BasePromptTemplate, "{rankings}") {
+		problem("BASE_PROMPT_TEMPLATE must contain the {rankings} placeholder")
+	}
+	if c.Classifier.UseHuggingFace {
+		if c.Classifier.HuggingFaceToken == "" {
+			problem("HUGGING_FACE_HUB_TOKEN is required when USE_HUGGING_FAME is true")
+		}
+		if c.Classifier.HuggingFaceModel == "" {
+			problem("HF_MODEL is required when USE_HUGGING_FAME is true")
+		}
+	} else if c.Classifier.OpenAIAPIKey == "" {
+		problem("OPENAI_API_KEY is required when USE_HUGGING_FAME is false")
+	}
+	if c.Classifier.Timeout <= 0 {
+		problem("CLASSIFIER_TIMEOUT must be positive")
+	}
+
+	recommendations := c.Recommendations
+	limits := []struct {
+		key   string
+		value int64
+	}{
+		{"RECOMMENDED_MOVIE_LIMIT", recommendations.MovieLimit},
+		{"RECOMMENDED_TV_SHOW_LIMIT", recommendations.TVShowLimit},
+		{"SIMILAR_TITLES_LIMIT", recommendations.SimilarTitlesLimit},
+		{"ONBOARDING_SAMPLE_SIZE", recommendations.OnboardingSampleSize},
+	}
+	for _, limit := range limits {
+		if limit.value < 1 || limit.value > MaxRecommendationLimit {
+			problem("%s must be between 1 and %d", limit.key, MaxRecommendationLimit)
+		}
+	}
+	if recommendations.TrendingRefreshInterval <= 0 {
+		problem("TRENDING_REFRESH_INTERVAL must be positive")
+	}
+	if recommendations.TrendingRefreshTimeout <= 0 {
+		problem("TRENDING_REFRESH_TIMEOUT must be positive")
+	}
+	weights := []struct {
+		key   string
+		value float64
+	}{
+		{"RECOMMENDATION_GENRE_WEIGHT", recommendations.Weights.Genre},
+		{"RECOMMENDATION_RANKING_WEIGHT", recommendations.Weights.Ranking},
+		{"RECOMMENDATION_RECENCY_WEIGHT", recommendations.Weights.Recency},
+		{"RECOMMENDATION_TRENDING_WEIGHT", recommendations.Weights.Trending},
+	}
+	for _, weight := range weights {
+		if weight.value < 0 {
+			problem("%s must not be negative", weight.key)
+		}
+	}
+
+	if c.Readiness.Timeout <= 0 {
+		problem("READINESS_TIMEOUT must be positive")
+	}
+
+	if c.Trash.Retention <= 0 {
+		problem("TRASH_RETENTION must be positive")
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		problem("TRASH_PURGE_INTERVAL must be positive")
+	}
+
+	return errors.Join(problems...)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func validConfig() *Config {
+	cfg := Default()
+	cfg.MongoURI = "mongodb://localhost:27017/"
+	cfg.DatabaseName = "Loomi-movies"
+	cfg.SecretKey = "an-access-token-secret-of-32-chars"
+	cfg.SecretRefreshKey = "a-refresh-token-secret-of-32-chars"
+	cfg.Classifier.BasePromptTemplate = "Pick one of {rankings} for: "
+	cfg.Classifier.HuggingFaceToken = "hf_token"
+	cfg.Classifier.HuggingFaceModel = "openai/gpt-oss-20b"
+	return cfg
+}
+
+func TestValidate_Success(t *testing.T) {
+	assert.NoError(t, validConfig().Validate())
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	cfg := validConfig()
+	cfg.MongoURI = ""
+	cfg.SecretKey = "short"
+	cfg.Classifier.HuggingFaceModel = ""
+	cfg.Recommendations.MovieLimit = 0
+
+	err := cfg.Validate()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "MONGODB_URI is required")
+	assert.Contains(t, err.Error(), "SECRET_KEY must be at least 32 characters long")
+	assert.Contains(t, err.Error(), "HF_MODEL is required")
+	assert.Contains(t, err.Error(), "RECOMMENDED_MOVIE_LIMIT must be between 1 and 50")
+}
+
+func TestValidate_OpenAIProvider(t *testing.T) {
+	cfg := validConfig()
+	cfg.Classifier.UseHuggingFace = false
+
+	err := cfg.Validate()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "OPENAI_API_KEY is required")
+}
+
+func TestValidate_ServerSettings(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.ShutdownTimeout = 0
+	cfg.MongoConnectRetries = -1
+
+	err := cfg.Validate()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SERVER_SHUTDOWN_TIMEOUT must be positive")
+	assert.Contains(t, err.Error(), "MONGODB_CONNECT_RETRIES must not be negative")
+}
+
+func TestValidate_TrashSettings(t *testing.T) {
+	cfg := validConfig()
+	cfg.Trash.Retention = 0
+	cfg.Trash.PurgeInterval = -time.Minute
+
+	err := cfg.Validate()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TRASH_RETENTION must be positive")
+	assert.Contains(t, err.Error(), "TRASH_PURGE_INTERVAL must be positive")
+}
+
+func TestLoad_EnvironmentOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yamlConfig := `
+log_level: debug
+mongodb_uri: mongodb://db:27017/
+database_name: from-file
+secret_key: an-access-token-secret-of-32-chars
+secret_refresh_key: a-refresh-token-secret-of-32-chars
+classifier:
+  base_prompt_template: "Pick one of {rankings} for: "
+  hugging_face_token: hf_token
+  hugging_face_model: openai/gpt-oss-20b
+recommendations:
+  movie_limit: 8
+  trending_refresh_interval: 5m
+`
+	assert.NoError(t, os.WriteFile(path, []byte(yamlConfig), 0o600))
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DATABASE_NAME", "from-env")
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "mongodb://db:27017/", cfg.MongoURI)
+	assert.Equal(t, "from-env", cfg.DatabaseName)
+	assert.Equal(t, int64(8), cfg.Recommendations.MovieLimit)
+	assert.Equal(t, int64(5), cfg.Recommendations.TVShowLimit)
+	assert.Equal(t, 5*time.Minute, cfg.Recommendations.TrendingRefreshInterval)
+	assert.Equal(t, slog.LevelDebug, cfg.LogLevel)
+}
+
+func TestLoad_InvalidEnvironmentValue(t *testing.T) {
+	t.Setenv("MONGODB_URI", "")
+	t.Setenv("RECOMMENDED_TV_SHOW_LIMIT", "many")
+
+	_, err := Load()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `RECOMMENDED_TV_SHOW_LIMIT has an invalid value "many"`)
+	assert.Contains(t, err.Error(), "MONGODB_URI is required")
+}
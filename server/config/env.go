@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envReader overrides configuration values with the environment variables
+// that are set, collecting the ones that cannot be parsed.
+type envReader struct {
+	problems error
+}
+
+func (e *envReader) string(key string, target *string) {
+	if value := os.Getenv(key); value != "" {
+		*target = value
+	}
+}
+
+func (e *envReader) bool(key string, target *bool) {
+	parseEnv(e, key, target, strconv.ParseBool)
+}
+
+func (e *envReader) int(key string, target *int) {
+	parseEnv(e, key, target, strconv.Atoi)
+}
+
+func (e *envReader) int64(key string, target *int64) {
+	parseEnv(e, key, target, func(value string) (int64, error) {
+		return strconv.ParseInt(value, 10, 64)
+	})
+}
+
+func (e *envReader) float(key string, target *float64) {
+	parseEnv(e, key, target, func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	})
+}
+
+func (e *envReader) duration(key string, target *time.Duration) {
+	parseEnv(e, key, target, time.ParseDuration)
+}
+
+func (e *envReader) level(key string, target *slog.Level) {
+	parseEnv(e, key, target, func(value string) (slog.Level, error) {
+		var level slog.Level
+		err := level.UnmarshalText([]byte(value))
+		return level, err
+	})
+}
+
+// parseEnv only assigns the value when it parses, so an invalid value is
+// reported as such rather than as an out of range zero value.
+func parseEnv[T any](e *envReader, key string, target *T, parse func(string) (T, error)) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+
+	parsed, err := parse(value)
+	if err != nil {
+		e.problems = errors.Join(e.problems, fmt.Errorf("%s has an invalid value %q", key, value))
+		return
+	}
+	*target = parsed
+}
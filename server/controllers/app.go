@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"server/classifier"
+	"server/config"
+	"server/repository"
+	"server/utils"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// App owns everything the handlers depend on. Handlers are built as methods
+// on it, so several instances with their own dependencies can coexist.
+type App struct {
+	Config     *config.Config
+	Client     *mongo.Client
+	Repos      *repository.Repositories
+	Classifier classifier.Classifier
+	Tokens     *utils.TokenService
+
+	workers sync.WaitGroup
+}
+
+// NewApp wires the application from its configuration. The Mongo client may
+// be nil when the repositories do not need it, as in tests.
+func NewApp(cfg *config.Config, client *mongo.Client, repos *repository.Repositories) *App {
+	return &App{
+		Config:     cfg,
+		Client:     client,
+		Repos:      repos,
+		Classifier: classifier.New(cfg.Classifier),
+		Tokens:     utils.NewTokenService(cfg.SecretKey, cfg.SecretRefreshKey),
+	}
+}
+
+// WaitForWorkers blocks until the background workers started by the app have
+// returned, or ctx is done.
+func (app *App) WaitForWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		app.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
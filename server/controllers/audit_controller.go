@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"server/apierror"
+	"server/logging"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// auditEntryKey holds, in the gin context, the entry the audit middleware
+// records once the handler is done.
+const auditEntryKey = "auditEntry"
+
+const (
+	// defaultAuditLimit is the number of audit entries listed when the
+	// request sets no limit.
+	defaultAuditLimit = 50
+	// maxAuditLimit caps the number of audit entries listed per request.
+	maxAuditLimit = 500
+)
+
+// Audit records an entry of the audit log for every request of the route it
+// precedes, successful or not. The actor is the authenticated user and the
+// target the imdb_id and user_id of the path; handlers complete the entry
+// through auditEntry.
+func (app *App) Audit(action, contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry := &models.AuditEntry{
+			Action:       action,
+			ContentType:  contentType,
+			ImdbID:       c.Param("imdb_id"),
+			TargetUserID: c.Param("user_id"),
+		}
+		entry.ActorID, _ = utils.GetUserIdFromContext(c)
+		entry.ActorRole, _ = utils.GetRoleFromContext(c)
+		c.Set(auditEntryKey, entry)
+
+		c.Next()
+
+		entry.Status = responseStatus(c)
+		entry.Outcome = models.AuditSuccess
+		if entry.Status >= http.StatusBadRequest {
+			entry.Outcome = models.AuditFailure
+		}
+		app.recordAudit(c, *entry)
+	}
+}
+
+// GetAuditLog lists the audit log, newest first. The actor_id and action
+// queries filter it, from and to (ISO-8601 dates or times, to exclusive
+// unless a date) bound it in time and limit and skip page through it.
+func (app *App) GetAuditLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		query := repository.AuditQuery{
+			ActorID: c.Query("actor_id"),
+			Action:  c.Query("action"),
+		}
+
+		var err error
+		if query.From, err = parseTimeQuery(c, "from"); err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+		if query.To, err = parseEndQuery(c, "to"); err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+		if query.Limit, err = parseBoundedLimitQuery(c, "limit", defaultAuditLimit, maxAuditLimit); err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+		if query.Skip, err = parseSkipQuery(c); err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		entries, err := app.Repos.Audit.Find(ctx, query)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch the audit log", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, entries)
+	}
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+// auditEntry returns the entry the audit middleware records for the request,
+// or a throwaway one when the route is not audited.
+func auditEntry(c *gin.Context) *models.AuditEntry {
+	if entry, ok := c.Get(auditEntryKey); ok {
+		return entry.(*models.AuditEntry)
+	}
+	return &models.AuditEntry{}
+}
+
+// recordAudit stores the entry, stamped with the request id. It outlives
+// the request, so a client hanging up does not skip it, and failures are
+// only logged.
+func (app *App) recordAudit(c *gin.Context, entry models.AuditEntry) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()),
+		app.Config.MongoOperationTimeout)
+	defer cancel()
+
+	entry.RequestID = logging.RequestID(ctx)
+	entry.CreatedAt = time.Now()
+
+	if err := app.Repos.Audit.Insert(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "Error recording audit entry", "action", entry.Action, "error", err)
+	}
+}
+
+// responseStatus is the status the request is answered with. An error
+// attached by the handler is only written by the error middleware later on.
+func responseStatus(c *gin.Context) int {
+	if !c.Writer.Written() && len(c.Errors) > 0 {
+		return apierror.From(c.Errors.Last().Err).Status
+	}
+	return c.Writer.Status()
+}
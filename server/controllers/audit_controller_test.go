@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/models"
+	"server/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogin(t *testing.T) {
+	router, app := setupTestRouter()
+	router.POST("/login", app.Audit(models.AuditLogin, ""), app.LoginUser())
+
+	password, err := HashPassword("SecurePass123!")
+	assert.NoError(t, err)
+	_, err = app.Repos.Users.Insert(t.Context(), models.User{
+		UserID: "user-1", Email: "login@example.com", Password: password, Role: "USER",
+	})
+	assert.NoError(t, err)
+
+	login := func(password string) int {
+		body, _ := json.Marshal(models.UserLogin{Email: "login@example.com", Password: password})
+		req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, login("SecurePass123!"))
+	assert.Equal(t, http.StatusUnauthorized, login("WrongPass123!"))
+
+	entries, err := app.Repos.Audit.Find(t.Context(), repository.AuditQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	assert.Equal(t, models.AuditLoginFailed, entries[0].Action)
+	assert.Equal(t, models.AuditFailure, entries[0].Outcome)
+	assert.Equal(t, http.StatusUnauthorized, entries[0].Status)
+	assert.Equal(t, "user-1", entries[0].ActorID)
+	assert.Equal(t, "login@example.com", entries[0].Email)
+
+	assert.Equal(t, models.AuditLogin, entries[1].Action)
+	assert.Equal(t, models.AuditSuccess, entries[1].Outcome)
+	assert.Equal(t, "USER", entries[1].ActorRole)
+}
+
+func TestAuditRoleChange(t *testing.T) {
+	router, app := setupTestRouter()
+	role := "ADMIN"
+	router.PATCH("/user/:user_id/role", func(c *gin.Context) {
+		c.Set("userId", "admin-1")
+		c.Set("role", role)
+	}, app.Audit(models.AuditRoleChange, ""), app.UpdateUserRole())
+	router.GET("/audit", func(c *gin.Context) {
+		c.Set("role", "ADMIN")
+	}, app.GetAuditLog())
+
+	_, err := app.Repos.Users.Insert(t.Context(), models.User{UserID: "user-1", Email: "user@example.com", Role: "USER"})
+	assert.NoError(t, err)
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("PATCH", "/user/user-1/role", `{"role": "ADMIN"}`).Code)
+	assert.Equal(t, http.StatusNotFound, request("PATCH", "/user/user-2/role", `{"role": "ADMIN"}`).Code)
+	role = "USER"
+	assert.Equal(t, http.StatusForbidden, request("PATCH", "/user/user-1/role", `{"role": "USER"}`).Code)
+
+	w := request("GET", "/audit?actor_id=admin-1&action=role_change", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var entries []models.AuditEntry
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, http.StatusForbidden, entries[0].Status)
+	assert.Equal(t, "USER", entries[0].ActorRole)
+	assert.Equal(t, http.StatusNotFound, entries[1].Status)
+	assert.Equal(t, models.AuditSuccess, entries[2].Outcome)
+	assert.Equal(t, "user-1", entries[2].TargetUserID)
+	assert.Equal(t, "USER -> ADMIN", entries[2].Detail)
+
+	from := time.Now().Add(time.Hour).Format(time.RFC3339)
+	w = request("GET", "/audit?from="+from, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/audit?to=yesterday", "").Code)
+
+	// A day as to covers all of it, a time stops short of it
+	today := time.Now().UTC().Format(time.DateOnly)
+	w = request("GET", "/audit?action=role_change&to="+today, "")
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Len(t, entries, 3)
+	w = request("GET", "/audit?action=role_change&to="+today+"T00:00:00Z", "")
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	// The audit log pages independently of the recommendation limits
+	assert.Equal(t, http.StatusOK, request("GET", "/audit?limit=500", "").Code)
+	assert.Equal(t, http.StatusBadRequest, request("GET", "/audit?limit=501", "").Code)
+}
@@ -0,0 +1,557 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Formats a catalog archive is written in: a single JSON document, or NDJSON
+// starting with a header line followed by one line per record.
+const (
+	ArchiveJSON   = "json"
+	ArchiveNDJSON = utils.FormatNDJSON
+)
+
+// Collections of an NDJSON archive, named after the fields of the JSON one.
+const (
+	archiveMovies   = "movies"
+	archiveTVShows  = "tv_shows"
+	archiveGenres   = "genres"
+	archiveRankings = "rankings"
+)
+
+// ErrInvalidArchive is returned by a restore in replace mode when titles of
+// the archive are invalid, in which case nothing was restored.
+var ErrInvalidArchive = errors.New("the archive holds invalid titles, nothing was restored")
+
+// archiveFormats maps the content types a restore is accepted in to the
+// format they hold.
+var archiveFormats = map[string]string{
+	"application/json":     ArchiveJSON,
+	"application/x-ndjson": ArchiveNDJSON,
+	"application/ndjson":   ArchiveNDJSON,
+	"application/jsonl":    ArchiveNDJSON,
+}
+
+// archiveLine is a line of an NDJSON archive: the header, with the version
+// and export time, or a record of a collection.
+type archiveLine struct {
+	Version    int             `json:"version,omitempty"`
+	ExportedAt time.Time       `json:"exported_at,omitzero"`
+	Collection string          `json:"collection,omitempty"`
+	Document   json.RawMessage `json:"document,omitempty"`
+}
+
+// ExportCatalog downloads the catalog archive, in NDJSON unless the format
+// query asks for json.
+func (app *App) ExportCatalog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		format := c.DefaultQuery("format", ArchiveNDJSON)
+		contentType := "application/x-ndjson"
+		switch format {
+		case ArchiveNDJSON:
+		case ArchiveJSON:
+			contentType = "application/json"
+		default:
+			utils.RespondError(c, apierror.BadRequest("format must be json or ndjson"))
+			return
+		}
+
+		archive, err := app.ExportCatalogArchive(c.Request.Context())
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to export the catalog", err))
+			return
+		}
+
+		filename := "loomi-catalog-" + archive.ExportedAt.Format("20060102-150405") + "." + format
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Status(http.StatusOK)
+		if err := WriteCatalogArchive(c.Writer, archive, format); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
+
+// RestoreCatalog restores the archive in the body, merged into the catalog
+// unless the mode query asks to replace it.
+func (app *App) RestoreCatalog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		mode := c.DefaultQuery("mode", models.RestoreMerge)
+		if mode != models.RestoreMerge && mode != models.RestoreReplace {
+			utils.RespondError(c, apierror.BadRequest("mode must be merge or replace"))
+			return
+		}
+
+		format, body, ok := readUpload(c, archiveFormats, "application/json or application/x-ndjson")
+		if !ok {
+			return
+		}
+		archive, err := ReadCatalogArchive(bytes.NewReader(body), format)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid archive: "+err.Error()).Wrap(err))
+			return
+		}
+
+		userId, _ := utils.GetUserIdFromContext(c)
+		report, err := app.RestoreCatalogArchive(c.Request.Context(), archive, mode, userId)
+		if err != nil {
+			if errors.Is(err, ErrInvalidArchive) {
+				utils.RespondError(c, invalidArchiveError(report))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to restore the catalog", err))
+			return
+		}
+		auditEntry(c).Detail = fmt.Sprintf("%s: %d movies, %d TV shows, %d genres, %d rankings",
+			mode, len(archive.Movies), len(archive.TVShows), report.Genres, report.Rankings)
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// ExportCatalogArchive takes a snapshot of the catalog.
+func (app *App) ExportCatalogArchive(ctx context.Context) (models.CatalogArchive, error) {
+	ctx, cancel := context.WithTimeout(ctx, app.Config.MongoOperationTimeout)
+	defer cancel()
+
+	archive := models.CatalogArchive{Version: models.CatalogArchiveVersion, ExportedAt: time.Now().UTC()}
+
+	var err error
+	if archive.Movies, err = app.Repos.Movies.FindAll(ctx); err != nil {
+		return archive, err
+	}
+	if archive.TVShows, err = app.Repos.TVShows.FindAll(ctx); err != nil {
+		return archive, err
+	}
+	if archive.Genres, err = app.Repos.Genres.FindAll(ctx); err != nil {
+		return archive, err
+	}
+	archive.Rankings, err = app.Repos.Rankings.FindAll(ctx)
+	return archive, err
+}
+
+// RestoreCatalogArchive writes the archive to the catalog, recording userId
+// as the author of the revisions. Merge upserts the titles by imdb_id, the
+// genres by genre_id and the rankings by ranking_value, leaving the others
+// alone. Replace first checks that every title is valid and appears once,
+// then swaps the titles, the trash included, the genres and the rankings for
+// the archived ones, each collection as a whole. Titles failing in merge mode
+// are reported and skipped.
+func (app *App) RestoreCatalogArchive(ctx context.Context, archive models.CatalogArchive,
+	mode, userId string) (models.RestoreReport, error) {
+
+	report := models.RestoreReport{Mode: mode}
+
+	for i := range archive.Genres {
+		if err := validate.Struct(archive.Genres[i]); err != nil {
+			return report, fmt.Errorf("genre %d: %s", i+1, validationReason(err))
+		}
+	}
+	for i := range archive.Rankings {
+		if err := validate.Struct(archive.Rankings[i]); err != nil {
+			return report, fmt.Errorf("ranking %d: %s", i+1, validationReason(err))
+		}
+	}
+
+	if mode == models.RestoreReplace {
+		report.Movies = invalidTitles(movieKind, archive.Movies)
+		report.TVShows = invalidTitles(tvShowKind, archive.TVShows)
+		if report.Movies.Failed > 0 || report.TVShows.Failed > 0 {
+			return report, ErrInvalidArchive
+		}
+	}
+
+	if err := app.restoreReferences(ctx, archive, mode); err != nil {
+		return report, err
+	}
+	report.Genres, report.Rankings = len(archive.Genres), len(archive.Rankings)
+
+	var err error
+	report.Movies, err = restoreTitles(ctx, app, app.Repos.Movies, movieKind, archive.Movies, mode, userId)
+	if err != nil {
+		return report, err
+	}
+	report.TVShows, err = restoreTitles(ctx, app, app.Repos.TVShows, tvShowKind, archive.TVShows, mode, userId)
+	return report, err
+}
+
+// WriteCatalogArchive writes the archive to w in the given format.
+func WriteCatalogArchive(w io.Writer, archive models.CatalogArchive, format string) error {
+	encoder := json.NewEncoder(w)
+	switch format {
+	case ArchiveJSON:
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(archive)
+	case ArchiveNDJSON:
+	default:
+		return errors.New("format must be json or ndjson")
+	}
+
+	if err := encoder.Encode(archiveLine{Version: archive.Version, ExportedAt: archive.ExportedAt}); err != nil {
+		return err
+	}
+	for _, collection := range []struct {
+		name      string
+		documents any
+	}{
+		{archiveGenres, archive.Genres},
+		{archiveRankings, archive.Rankings},
+		{archiveMovies, archive.Movies},
+		{archiveTVShows, archive.TVShows},
+	} {
+		if err := encodeCollection(encoder, collection.name, collection.documents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadCatalogArchive reads an archive in the given format, refusing the
+// ones of a version this server does not know.
+func ReadCatalogArchive(r io.Reader, format string) (models.CatalogArchive, error) {
+	var archive models.CatalogArchive
+	var err error
+	switch format {
+	case ArchiveJSON:
+		err = readJSONArchive(r, &archive)
+	case ArchiveNDJSON:
+		err = readNDJSONArchive(r, &archive)
+	default:
+		err = errors.New("format must be json or ndjson")
+	}
+	if err != nil {
+		return archive, err
+	}
+
+	switch {
+	case archive.Version == 0:
+		return archive, errors.New("the archive has no version")
+	case archive.Version > models.CatalogArchiveVersion:
+		return archive, fmt.Errorf("archive version %d is newer than the supported version %d",
+			archive.Version, models.CatalogArchiveVersion)
+	}
+	return archive, nil
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+func encodeCollection(encoder *json.Encoder, name string, documents any) error {
+	data, err := json.Marshal(documents)
+	if err != nil {
+		return err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for _, document := range raw {
+		if err := encoder.Encode(archiveLine{Collection: name, Document: document}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readJSONArchive(r io.Reader, archive *models.CatalogArchive) error {
+	var document json.RawMessage
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return err
+	}
+
+	var header archiveLine
+	if err := json.Unmarshal(document, &header); err != nil {
+		return err
+	}
+	document, err := upgradeArchiveDocument(header.Version, document)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(document, archive)
+}
+
+func readNDJSONArchive(r io.Reader, archive *models.CatalogArchive) error {
+	var lineErr error
+	header := false
+	err := utils.DecodeRecords(r, utils.FormatNDJSON, func(line int, record archiveLine, err error) {
+		if lineErr != nil {
+			return
+		}
+		if err == nil {
+			err = readArchiveLine(archive, record, &header)
+		}
+		if err != nil {
+			lineErr = fmt.Errorf("line %d: %w", line, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return lineErr
+}
+
+// readArchiveLine adds a line to the archive. The header must come first.
+func readArchiveLine(archive *models.CatalogArchive, line archiveLine, header *bool) error {
+	if !*header {
+		if line.Collection != "" {
+			return errors.New("the archive must start with its header")
+		}
+		*header = true
+		archive.Version, archive.ExportedAt = line.Version, line.ExportedAt
+		return nil
+	}
+
+	var target any
+	switch line.Collection {
+	case archiveMovies:
+		archive.Movies = append(archive.Movies, models.Movie{})
+		target = &archive.Movies[len(archive.Movies)-1]
+	case archiveTVShows:
+		archive.TVShows = append(archive.TVShows, models.TVShow{})
+		target = &archive.TVShows[len(archive.TVShows)-1]
+	case archiveGenres:
+		archive.Genres = append(archive.Genres, models.Genre{})
+		target = &archive.Genres[len(archive.Genres)-1]
+	case archiveRankings:
+		archive.Rankings = append(archive.Rankings, models.Ranking{})
+		target = &archive.Rankings[len(archive.Rankings)-1]
+	default:
+		return fmt.Errorf("unknown collection %q", line.Collection)
+	}
+
+	document, err := upgradeArchiveDocument(archive.Version, line.Document)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(document, target)
+}
+
+// archiveDateFields are the dates version 1 archives hold as entered.
+var archiveDateFields = []string{"air_date", "first_aired"}
+
+// upgradeArchiveDocument rewrites a document of an archive of an earlier
+// version as the current version writes it.
+func upgradeArchiveDocument(version int, document json.RawMessage) (json.RawMessage, error) {
+	if version != 1 {
+		return document, nil
+	}
+
+	var value any
+	if err := json.Unmarshal(document, &value); err != nil {
+		return nil, err
+	}
+	if err := upgradeDates(value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// upgradeDates rewrites the dates of archiveDateFields found at any depth of
+// the decoded JSON value as RFC 3339 timestamps.
+func upgradeDates(value any) error {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, field := range value {
+			date, isString := field.(string)
+			if !isString || !slices.Contains(archiveDateFields, key) {
+				if err := upgradeDates(field); err != nil {
+					return err
+				}
+				continue
+			}
+
+			parsed, err := models.ParseDate(date)
+			if err != nil {
+				return fmt.Errorf("%s %q %w", key, date, err)
+			}
+			value[key] = parsed.Format(time.RFC3339)
+		}
+	case []any:
+		for _, item := range value {
+			if err := upgradeDates(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (app *App) restoreReferences(ctx context.Context, archive models.CatalogArchive, mode string) error {
+	ctx, cancel := context.WithTimeout(ctx, app.Config.MongoOperationTimeout)
+	defer cancel()
+
+	if mode == models.RestoreReplace {
+		if err := app.Repos.Genres.ReplaceAll(ctx, archive.Genres); err != nil {
+			return err
+		}
+		return app.Repos.Rankings.ReplaceAll(ctx, archive.Rankings)
+	}
+
+	for _, genre := range archive.Genres {
+		if err := app.Repos.Genres.Upsert(ctx, genre); err != nil {
+			return err
+		}
+	}
+	for _, ranking := range archive.Rankings {
+		if err := app.Repos.Rankings.Upsert(ctx, ranking); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreTitles upserts the archived titles one by one in merge mode. In
+// replace mode they take the place of every title at once, which leaves the
+// titles as they were when it fails. The titles already stored, trashed or
+// not, are then reported and recorded as updated from what they were.
+func restoreTitles[T any](ctx context.Context, app *App, titles repository.TitleRepository[T],
+	kind titleKind[T], archived []T, mode, userId string) (models.ImportReport, error) {
+
+	report := models.ImportReport{Rows: []models.ImportRow{}}
+	if mode == models.RestoreReplace {
+		replaceCtx, cancel := context.WithTimeout(ctx, app.Config.MongoOperationTimeout)
+		defer cancel()
+		stored, err := storedTitles(replaceCtx, titles, kind)
+		if err != nil {
+			return report, err
+		}
+		if err := titles.ReplaceAll(replaceCtx, archived); err != nil {
+			return report, err
+		}
+
+		for i := range archived {
+			imdbID := kind.imdbID(&archived[i])
+			status, action := models.ImportCreated, models.RevisionCreate
+			var before *T
+			if title, ok := stored[imdbID]; ok {
+				status, action, before = models.ImportUpdated, models.RevisionUpdate, &title
+			}
+			recordRestoredTitle(ctx, app, titles, imdbID, action, userId, before)
+			addImportRow(&report, models.ImportRow{Row: i + 1, ImdbID: imdbID, Status: status})
+		}
+		return report, nil
+	}
+
+	for i, title := range archived {
+		row := models.ImportRow{Row: i + 1, ImdbID: kind.imdbID(&title)}
+		row.Status, row.Reason = importTitle(ctx, app, titles, kind, title, userId)
+		addImportRow(&report, row)
+	}
+	return report, nil
+}
+
+// storedTitles returns the stored titles, those in the trash included, by
+// IMDB ID.
+func storedTitles[T any](ctx context.Context, titles repository.TitleRepository[T],
+	kind titleKind[T]) (map[string]T, error) {
+
+	live, err := titles.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := titles.FindDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]T, len(live)+len(deleted))
+	for _, title := range append(live, deleted...) {
+		stored[kind.imdbID(&title)] = title
+	}
+	return stored, nil
+}
+
+// recordRestoredTitle records the write of a title a replace restored,
+// before being the title it replaced if any. Failures are only logged, the
+// title is already written.
+func recordRestoredTitle[T any](ctx context.Context, app *App, titles repository.TitleRepository[T],
+	imdbID, action, userId string, before *T) {
+
+	ctx, cancel := context.WithTimeout(ctx, app.Config.MongoOperationTimeout)
+	defer cancel()
+
+	if err := saveRevision(ctx, app, titles, imdbID, action, userId, before); err != nil {
+		slog.ErrorContext(ctx, "Error recording revision", "action", action, "imdb_id", imdbID, "error", err)
+	}
+}
+
+// invalidTitles reports the archived titles that are not valid or repeat
+// the IMDB ID or _id of an earlier one.
+func invalidTitles[T any](kind titleKind[T], archived []T) models.ImportReport {
+	report := models.ImportReport{Rows: []models.ImportRow{}}
+	imdbIDs, ids := map[string]bool{}, map[bson.ObjectID]bool{}
+	for i := range archived {
+		imdbID, id := kind.imdbID(&archived[i]), kind.id(&archived[i])
+		reason := checkTitle(kind, &archived[i])
+		switch {
+		case reason != "":
+		case imdbIDs[imdbID]:
+			reason = "The IMDB ID is repeated in the archive"
+		case !id.IsZero() && ids[id]:
+			reason = "The _id is repeated in the archive"
+		}
+		imdbIDs[imdbID] = true
+		if !id.IsZero() {
+			ids[id] = true
+		}
+
+		if reason != "" {
+			addImportRow(&report, models.ImportRow{
+				Row:    i + 1,
+				ImdbID: imdbID,
+				Status: models.ImportFailed,
+				Reason: reason,
+			})
+		}
+	}
+	return report
+}
+
+// invalidArchiveError lists the invalid titles of an archive as the fields
+// of a validation error, named after their position in the archive.
+func invalidArchiveError(report models.RestoreReport) *apierror.Error {
+	apiErr := apierror.New(http.StatusBadRequest, apierror.CodeValidationFailed, ErrInvalidArchive.Error())
+	for _, collection := range []struct {
+		name string
+		rows []models.ImportRow
+	}{
+		{archiveMovies, report.Movies.Rows},
+		{archiveTVShows, report.TVShows.Rows},
+	} {
+		for _, row := range collection.rows {
+			apiErr.Fields = append(apiErr.Fields, apierror.FieldError{
+				Field:   collection.name + "[" + strconv.Itoa(row.Row-1) + "]",
+				Rule:    "valid",
+				Message: row.Reason,
+			})
+		}
+	}
+	return apiErr
+}
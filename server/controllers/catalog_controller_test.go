@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"server/apierror"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCatalogRouter() (*gin.Engine, *App) {
+	router, app := setupTestRouter()
+	router.GET("/catalog/export", asAdmin, app.ExportCatalog())
+	router.POST("/catalog/restore", asAdmin, app.RestoreCatalog())
+	return router, app
+}
+
+// loadCatalogFixture restores testdata/catalog.ndjson into the app.
+func loadCatalogFixture(t *testing.T, app *App) {
+	t.Helper()
+
+	file, err := os.Open("testdata/catalog.ndjson")
+	assert.NoError(t, err)
+	defer file.Close()
+
+	archive, err := ReadCatalogArchive(file, ArchiveNDJSON)
+	assert.NoError(t, err)
+	_, err = app.RestoreCatalogArchive(t.Context(), archive, models.RestoreReplace, "")
+	assert.NoError(t, err)
+}
+
+func TestCatalogExportAndRestore(t *testing.T) {
+	contentTypes := map[string]string{ArchiveNDJSON: "application/x-ndjson", ArchiveJSON: "application/json"}
+	for format, contentType := range contentTypes {
+		t.Run(format, func(t *testing.T) {
+			router, app := setupCatalogRouter()
+			loadCatalogFixture(t, app)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/catalog/export?format="+format, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Header().Get("Content-Disposition"), "."+format)
+			exported := w.Body.Bytes()
+
+			// Replace a catalog that has diverged from the archive
+			target, other := setupCatalogRouter()
+			_, err := other.Repos.Movies.Insert(t.Context(), testMovie("tt0000001", "Test Movie"))
+			assert.NoError(t, err)
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("POST", "/catalog/restore?mode=replace", bytes.NewReader(exported))
+			req.Header.Set("Content-Type", contentType)
+			target.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var report models.RestoreReport
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, 2, report.Movies.Created)
+			assert.Equal(t, 1, report.TVShows.Created)
+			assert.Equal(t, 2, report.Genres)
+
+			movies, err := other.Repos.Movies.FindAll(t.Context())
+			assert.NoError(t, err)
+			assert.Len(t, movies, 2)
+			tvShow, err := other.Repos.TVShows.FindByImdbID(t.Context(), "tt0903747")
+			assert.NoError(t, err)
+			assert.Equal(t, "Pilot", tvShow.Seasons[0].Episodes[0].EpisodeTitle)
+			// The fixture is a version 1 archive, holding dates as entered
+			assert.Equal(t, models.NewDate(2008, 1, 20), tvShow.Seasons[0].Episodes[0].AirDate)
+			rankings, err := other.Repos.Rankings.FindAll(t.Context())
+			assert.NoError(t, err)
+			assert.Len(t, rankings, 2)
+		})
+	}
+}
+
+func TestRestoreCatalog_Merge(t *testing.T) {
+	_, app := setupCatalogRouter()
+	ctx := t.Context()
+	takenID, err := app.Repos.Movies.Insert(ctx, testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+	_, err = app.Repos.Movies.Insert(ctx, testMovie("tt0111161", "Old Title"))
+	assert.NoError(t, err)
+
+	clashing := testMovie("tt0000003", "Clashing Movie")
+	clashing.ID = takenID
+	archive := models.CatalogArchive{
+		Version: models.CatalogArchiveVersion,
+		Movies: []models.Movie{
+			testMovie("tt0111161", "The Shawshank Redemption"),
+			{ImdbID: "tt0000002"},
+			clashing,
+		},
+	}
+	report, err := app.RestoreCatalogArchive(ctx, archive, models.RestoreMerge, "admin-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Movies.Updated)
+	assert.Equal(t, 2, report.Movies.Failed)
+	assert.Equal(t, 2, report.Movies.Rows[1].Row)
+	assert.Equal(t, "The _id belongs to another movie", report.Movies.Rows[2].Reason)
+
+	movies, err := app.Repos.Movies.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, movies, 2)
+}
+
+func TestRestoreCatalog_ReplaceRecordsPreviousTitles(t *testing.T) {
+	_, app := setupCatalogRouter()
+	ctx := t.Context()
+	for _, imdbID := range []string{"tt0000001", "tt0000002"} {
+		_, err := app.Repos.Movies.Insert(ctx, testMovie(imdbID, "Old Title"))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, app.Repos.Movies.Delete(ctx, "tt0000002", "admin-1", 0))
+
+	archive := models.CatalogArchive{
+		Version: models.CatalogArchiveVersion,
+		Movies: []models.Movie{
+			testMovie("tt0000001", "New Title"),
+			testMovie("tt0000002", "Old Title"),
+			testMovie("tt0000003", "Another Movie"),
+		},
+	}
+	report, err := app.RestoreCatalogArchive(ctx, archive, models.RestoreReplace, "admin-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Movies.Updated)
+	assert.Equal(t, 1, report.Movies.Created)
+
+	latest := func(imdbID string) models.Revision {
+		revisions, err := app.Repos.Revisions.FindByTitle(ctx, models.ContentTypeMovie, imdbID)
+		assert.NoError(t, err)
+		revision, err := app.Repos.Revisions.FindByID(ctx, revisions[0].ID)
+		assert.NoError(t, err)
+		return revision
+	}
+
+	revision := latest("tt0000001")
+	assert.Equal(t, models.RevisionUpdate, revision.Action)
+	assert.Equal(t, []models.FieldChange{{Field: "title", Old: "Old Title", New: "New Title"}}, revision.Changes)
+
+	// The movie in the trash is restored as it was
+	revision = latest("tt0000002")
+	assert.Equal(t, models.RevisionUpdate, revision.Action)
+	assert.Len(t, revision.Changes, 2)
+	assert.Equal(t, "deleted_at", revision.Changes[0].Field)
+	assert.Equal(t, "deleted_by", revision.Changes[1].Field)
+
+	revision = latest("tt0000003")
+	assert.Equal(t, models.RevisionCreate, revision.Action)
+}
+
+func TestRestoreCatalog_Rejected(t *testing.T) {
+	router, app := setupCatalogRouter()
+	loadCatalogFixture(t, app)
+
+	restore := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/catalog/restore?mode=replace", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	invalid, _ := json.Marshal(models.CatalogArchive{
+		Version: models.CatalogArchiveVersion,
+		Movies:  []models.Movie{testMovie("tt0000001", "Test Movie"), {ImdbID: "tt0000002"}},
+	})
+	w := restore(string(invalid))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, apierror.CodeValidationFailed, response.Error.Code)
+	assert.Equal(t, "movies[1]", response.Error.Fields[0].Field)
+
+	repeated, _ := json.Marshal(models.CatalogArchive{
+		Version: models.CatalogArchiveVersion,
+		Movies:  []models.Movie{testMovie("tt0000001", "Test Movie"), testMovie("tt0000001", "Test Movie")},
+	})
+	w = restore(string(repeated))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "The IMDB ID is repeated in the archive")
+
+	// Nothing was replaced
+	movies, err := app.Repos.Movies.FindAll(t.Context())
+	assert.NoError(t, err)
+	assert.Len(t, movies, 2)
+
+	newer := models.CatalogArchiveVersion + 1
+	w = restore(fmt.Sprintf(`{"version": %d, "movies": []}`, newer))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), fmt.Sprintf("archive version %d is newer", newer))
+}
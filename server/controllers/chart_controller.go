@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Weight of each event type in a title's trending score.
+const (
+	viewEventWeight      = 1.0
+	watchlistEventWeight = 3.0
+	ratingEventWeight    = 2.0
+)
+
+const (
+	// chartSize is the number of entries kept in every chart.
+	chartSize = 20
+	// chartCandidates caps the titles considered per window and content type
+	// before they are split into genre charts.
+	chartCandidates = 500
+)
+
+var chartWindows = map[string]time.Duration{
+	models.ChartWindowDaily:  24 * time.Hour,
+	models.ChartWindowWeekly: 7 * 24 * time.Hour,
+}
+
+// GetTrendingChart serves a precomputed chart. The window query is daily or
+// weekly (default), content_type is movie (default) or tv_show, and genre
+// narrows the chart to a single genre.
+func (app *App) GetTrendingChart() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		window := c.DefaultQuery("window", models.ChartWindowWeekly)
+		if _, ok := chartWindows[window]; !ok {
+			utils.RespondError(c, apierror.BadRequest("window must be daily or weekly"))
+			return
+		}
+		contentType := c.DefaultQuery("content_type", models.ContentTypeMovie)
+		if app.Repos.Catalog(contentType) == nil {
+			utils.RespondError(c, apierror.BadRequest("content_type must be movie or tv_show"))
+			return
+		}
+		genre := c.DefaultQuery("genre", models.ChartAllGenres)
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		chart, err := app.Repos.Charts.FindByID(ctx, models.ChartID(window, contentType, genre))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("Chart not available"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to fetch chart", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, chart)
+	}
+}
+
+// StartTrendingChartRefresher rebuilds the charts right away and then every
+// configured refresh interval until ctx is cancelled. A refresh in progress
+// when ctx is cancelled is allowed to complete, see WaitForWorkers.
+func (app *App) StartTrendingChartRefresher(ctx context.Context) {
+	app.workers.Add(1)
+	go func() {
+		defer app.workers.Done()
+
+		ticker := time.NewTicker(app.Config.Recommendations.TrendingRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := app.RefreshTrendingCharts(context.WithoutCancel(ctx)); err != nil {
+				slog.Error("Error refreshing trending charts", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RefreshTrendingCharts recomputes every chart from the title events of its
+// window, replaces the stored chart documents and drops events too old to
+// count in any window, within the configured refresh timeout.
+func (app *App) RefreshTrendingCharts(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, app.Config.Recommendations.TrendingRefreshTimeout)
+	defer cancel()
+
+	now := time.Now()
+	var longestWindow time.Duration
+
+	for window, length := range chartWindows {
+		longestWindow = max(longestWindow, length)
+
+		for _, contentType := range []string{models.ContentTypeMovie, models.ContentTypeTVShow} {
+			entries, err := app.getChartEntries(ctx, contentType, now.Add(-length))
+			if err != nil {
+				return err
+			}
+
+			// Charts of genres nobody interacted with in this run are dropped
+			err = app.Repos.Charts.ReplaceAll(ctx, window, contentType, buildCharts(window, contentType, entries, now))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return app.Repos.Events.DeleteBefore(ctx, now.Add(-longestWindow))
+}
+
+// getChartEntries counts the events of each title since the given time,
+// scores them and joins the catalog details, best scores first.
+func (app *App) getChartEntries(ctx context.Context, contentType string,
+	since time.Time) ([]models.ChartEntry, error) {
+
+	counts, err := app.Repos.Events.CountSince(ctx, contentType, since)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.ChartEntry, 0, len(counts))
+	for _, count := range counts {
+		entries = append(entries, models.ChartEntry{
+			ImdbID:    count.ImdbID,
+			Views:     count.Views,
+			Watchlist: count.Watchlist,
+			Ratings:   count.Ratings,
+			Score: viewEventWeight*float64(count.Views) +
+				watchlistEventWeight*float64(count.Watchlist) +
+				ratingEventWeight*float64(count.Ratings),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].ImdbID < entries[j].ImdbID
+	})
+	if len(entries) > chartCandidates {
+		entries = entries[:chartCandidates]
+	}
+
+	imdbIDs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		imdbIDs = append(imdbIDs, entry.ImdbID)
+	}
+	summaries, err := app.Repos.Catalog(contentType).Summaries(ctx, imdbIDs)
+	if err != nil {
+		return nil, err
+	}
+	byImdbID := make(map[string]models.RecommendationItem, len(summaries))
+	for _, summary := range summaries {
+		byImdbID[summary.ImdbID] = summary
+	}
+
+	// Titles removed from the catalog drop out of the charts
+	joined := make([]models.ChartEntry, 0, len(entries))
+	for _, entry := range entries {
+		summary, ok := byImdbID[entry.ImdbID]
+		if !ok {
+			continue
+		}
+		entry.Title = summary.Title
+		entry.PosterPath = summary.PosterPath
+		entry.Genre = summary.Genre
+		joined = append(joined, entry)
+	}
+
+	return joined, nil
+}
+
+// buildCharts splits the scored entries into the all-genres chart and one
+// chart per genre, each keeping its chartSize best entries.
+func buildCharts(window, contentType string, entries []models.ChartEntry, now time.Time) []models.Chart {
+	byGenre := map[string][]models.ChartEntry{models.ChartAllGenres: {}}
+	for _, entry := range entries {
+		byGenre[models.ChartAllGenres] = append(byGenre[models.ChartAllGenres], entry)
+		for _, genre := range entry.Genre {
+			byGenre[genre.GenreName] = append(byGenre[genre.GenreName], entry)
+		}
+	}
+
+	charts := make([]models.Chart, 0, len(byGenre))
+	for genre, genreEntries := range byGenre {
+		if len(genreEntries) > chartSize {
+			genreEntries = genreEntries[:chartSize]
+		}
+		ranked := make([]models.ChartEntry, len(genreEntries))
+		for i, entry := range genreEntries {
+			entry.Rank = i + 1
+			ranked[i] = entry
+		}
+
+		charts = append(charts, models.Chart{
+			ChartID:     models.ChartID(window, contentType, genre),
+			Window:      window,
+			ContentType: contentType,
+			Genre:       genre,
+			Entries:     ranked,
+			GeneratedAt: now,
+		})
+	}
+
+	sort.Slice(charts, func(i, j int) bool { return charts[i].ChartID < charts[j].ChartID })
+	return charts
+}
+
+// recordTitleEvent stores an interaction for the trending charts. Failures
+// are only logged, they must never fail the request that triggered them.
+func (app *App) recordTitleEvent(c *gin.Context, imdbID, contentType, eventType string) {
+	userId, _ := utils.GetUserIdFromContext(c)
+
+	ctx, cancel := app.dbContext(c)
+	defer cancel()
+
+	err := app.Repos.Events.Insert(ctx, models.TitleEvent{
+		ImdbID:      imdbID,
+		ContentType: contentType,
+		EventType:   eventType,
+		UserID:      userId,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Error recording title event",
+			"event_type", eventType, "imdb_id", imdbID, "error", err)
+	}
+}
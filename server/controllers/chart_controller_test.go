@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTrendingCharts_Windows(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/charts/trending", asUser, app.GetTrendingChart())
+
+	ctx := t.Context()
+	for _, movie := range []models.Movie{
+		rankedMovie("tt0000001", 1, "Action"),
+		rankedMovie("tt0000002", 2, "Comedy"),
+		rankedMovie("tt0000003", 3, "Action"),
+	} {
+		_, err := app.Repos.Movies.Insert(ctx, movie)
+		assert.NoError(t, err)
+	}
+
+	now := time.Now()
+	events := []struct {
+		imdbID    string
+		eventType string
+		age       time.Duration
+		count     int
+	}{
+		// Four views two days ago only count in the weekly window
+		{"tt0000001", models.TitleEventView, 48 * time.Hour, 4},
+		// A watchlist add an hour ago counts in both
+		{"tt0000002", models.TitleEventWatchlist, time.Hour, 1},
+		// Events older than a week count in neither and are dropped
+		{"tt0000003", models.TitleEventRating, 8 * 24 * time.Hour, 5},
+		// Events of titles no longer in the catalog are left out
+		{"tt0000009", models.TitleEventView, time.Hour, 9},
+	}
+	for _, event := range events {
+		for range event.count {
+			err := app.Repos.Events.Insert(ctx, models.TitleEvent{
+				ImdbID:      event.imdbID,
+				ContentType: models.ContentTypeMovie,
+				EventType:   event.eventType,
+				CreatedAt:   now.Add(-event.age),
+			})
+			assert.NoError(t, err)
+		}
+	}
+
+	assert.NoError(t, app.RefreshTrendingCharts(ctx))
+
+	counts, err := app.Repos.Events.CountSince(ctx, models.ContentTypeMovie, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, counts, 3)
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		want   []string
+	}{
+		{"weekly by default", "", http.StatusOK, []string{"tt0000001", "tt0000002"}},
+		{"daily", "?window=daily", http.StatusOK, []string{"tt0000002"}},
+		{"genre", "?window=weekly&genre=Action", http.StatusOK, []string{"tt0000001"}},
+		{"genre absent from the window", "?window=daily&genre=Action", http.StatusNotFound, nil},
+		{"unknown window", "?window=monthly", http.StatusBadRequest, nil},
+		{"unknown content type", "?content_type=book", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/charts/trending"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var chart models.Chart
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &chart))
+			imdbIDs := []string{}
+			for i, entry := range chart.Entries {
+				assert.Equal(t, i+1, entry.Rank)
+				imdbIDs = append(imdbIDs, entry.ImdbID)
+			}
+			assert.Equal(t, tt.want, imdbIDs)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Accessors of the version the ETag of a title is derived from.
+var (
+	movieVersion  = func(m *models.Movie) *int64 { return &m.Version }
+	tvShowVersion = func(s *models.TVShow) *int64 { return &s.Version }
+)
+
+// respondWithETag writes the document with the ETag of its version, or a
+// 304 without body when the If-None-Match header already lists that ETag.
+func respondWithETag(c *gin.Context, version int64, document any) {
+	etag := utils.ETag(version)
+	c.Header("ETag", etag)
+
+	if utils.ETagMatches(c.GetHeader("If-None-Match"), etag, true) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, document)
+}
+
+// findForWrite loads the title a write applies to and checks it against
+// the If-Match header when sent. Otherwise it responds with the error and
+// returns false. Writing with the version of the loaded title keeps a write
+// landing in between from being lost.
+func findForWrite[T any](ctx context.Context, c *gin.Context, titles repository.TitleRepository[T],
+	imdbID, kind string, version func(*T) *int64) (T, bool) {
+
+	title, err := titles.FindByImdbID(ctx, imdbID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.RespondError(c, apierror.NotFound(kind+" not found"))
+			return title, false
+		}
+		utils.RespondError(c, apierror.Internal("Failed to fetch "+kind, err))
+		return title, false
+	}
+
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" && !utils.ETagMatches(ifMatch, utils.ETag(*version(&title)), false) {
+		utils.RespondError(c, versionConflict(kind))
+		return title, false
+	}
+	return title, true
+}
+
+func versionConflict(kind string) *apierror.Error {
+	return apierror.PreconditionFailed(kind + " was modified since it was read")
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"server/buildinfo"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	statusUp      = "up"
+	statusDown    = "down"
+	statusSkipped = "skipped"
+)
+
+// dependencyStatus is the outcome of the readiness check of one dependency.
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthCheck is the liveness probe, it only tells the process is serving.
+func (app *App) HealthCheck() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// ReadinessCheck checks every dependency concurrently, each within the
+// configured timeout, and answers 503 unless all of them are up or skipped.
+func (app *App) ReadinessCheck() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checks := map[string]func(context.Context) error{
+			"mongodb": app.Repos.Health.Ping,
+			"indexes": app.checkIndexes,
+		}
+		if app.Config.Readiness.CheckClassifier {
+			checks["classifier"] = app.Classifier.Check
+		}
+
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		results := map[string]dependencyStatus{
+			"classifier": {Status: statusSkipped},
+		}
+		for name, check := range checks {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				status := app.runCheck(c.Request.Context(), check)
+
+				mu.Lock()
+				results[name] = status
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		ready := true
+		for _, result := range results {
+			if result.Status == statusDown {
+				ready = false
+			}
+		}
+
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "checks": results})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": results})
+	}
+}
+
+func (app *App) GetVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildinfo.Get())
+	}
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+func (app *App) runCheck(ctx context.Context, check func(context.Context) error) dependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, app.Config.Readiness.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	status := dependencyStatus{Status: statusUp, LatencyMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		status.Status = statusDown
+		status.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			status.Error = fmt.Sprintf("timed out after %s", app.Config.Readiness.Timeout)
+		}
+	}
+
+	return status
+}
+
+func (app *App) checkIndexes(ctx context.Context) error {
+	missing, err := app.Repos.Health.MissingIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing indexes: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubHealthChecker struct {
+	pingErr error
+	missing []string
+}
+
+func (s stubHealthChecker) Ping(_ context.Context) error {
+	return s.pingErr
+}
+
+func (s stubHealthChecker) MissingIndexes(_ context.Context) ([]string, error) {
+	return s.missing, nil
+}
+
+// slowClassifier blocks every call until its context is done.
+type slowClassifier struct{}
+
+func (slowClassifier) Classify(ctx context.Context, _ string, _ []models.Ranking) (string, int, error) {
+	<-ctx.Done()
+	return "", 0, ctx.Err()
+}
+
+func (slowClassifier) Check(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type readinessResponse struct {
+	Status string                      `json:"status"`
+	Checks map[string]dependencyStatus `json:"checks"`
+}
+
+func getReadiness(t *testing.T, app *App) (int, readinessResponse) {
+	router, _ := setupTestRouter()
+	router.GET("/readyz", app.ReadinessCheck())
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response readinessResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestReadinessCheck_Ready(t *testing.T) {
+	_, app := setupTestRouter()
+
+	code, response := getReadiness(t, app)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response.Status)
+	assert.Equal(t, statusUp, response.Checks["mongodb"].Status)
+	assert.Equal(t, statusUp, response.Checks["indexes"].Status)
+	assert.Equal(t, statusSkipped, response.Checks["classifier"].Status)
+}
+
+func TestReadinessCheck_ReportsEachDependency(t *testing.T) {
+	_, app := setupTestRouter()
+	app.Repos.Health = stubHealthChecker{missing: []string{"movies.imdb_id_1"}}
+	app.Config.Readiness.CheckClassifier = true
+	app.Config.Readiness.Timeout = 10 * time.Millisecond
+	app.Classifier = slowClassifier{}
+
+	code, response := getReadiness(t, app)
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", response.Status)
+	assert.Equal(t, statusUp, response.Checks["mongodb"].Status)
+	assert.Equal(t, statusDown, response.Checks["indexes"].Status)
+	assert.Contains(t, response.Checks["indexes"].Error, "movies.imdb_id_1")
+	assert.Equal(t, statusDown, response.Checks["classifier"].Status)
+	assert.Equal(t, "timed out after 10ms", response.Checks["classifier"].Error)
+}
+
+func TestHealthCheck(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/healthz", app.HealthCheck())
+	router.GET("/version", app.GetVersion())
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/version", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":"dev"`)
+}
@@ -0,0 +1,16 @@
+package controllers
+
+import "github.com/gin-gonic/gin"
+
+// asUser authenticates the request as user-1 with the USER role, as the
+// auth middleware would.
+func asUser(c *gin.Context) {
+	c.Set("userId", "user-1")
+	c.Set("role", "USER")
+}
+
+// asAdmin authenticates the request as admin-1 with the ADMIN role.
+func asAdmin(c *gin.Context) {
+	c.Set("userId", "admin-1")
+	c.Set("role", "ADMIN")
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// maxImportBytes bounds the body of an import or restore request, read
+// whole before any title is written.
+const maxImportBytes = 32 << 20
+
+// importFormats maps the content types an import is accepted in to the
+// format they hold.
+var importFormats = map[string]string{
+	"text/csv":             utils.FormatCSV,
+	"application/x-ndjson": utils.FormatNDJSON,
+	"application/ndjson":   utils.FormatNDJSON,
+	"application/jsonl":    utils.FormatNDJSON,
+}
+
+// titleKind holds what the bulk writes need to know of a content type:
+// its name in messages, the accessors of its _id, IMDB ID and version, and
+// the rules it must follow beyond its validate tags.
+type titleKind[T any] struct {
+	name    string
+	id      func(*T) bson.ObjectID
+	imdbID  func(*T) string
+	version func(*T) *int64
+	check   func(*T) *apierror.Error
+}
+
+var (
+	movieKind = titleKind[models.Movie]{
+		name:    "movie",
+		id:      func(m *models.Movie) bson.ObjectID { return m.ID },
+		imdbID:  func(m *models.Movie) string { return m.ImdbID },
+		version: movieVersion,
+	}
+	tvShowKind = titleKind[models.TVShow]{
+		name:    "TV show",
+		id:      func(s *models.TVShow) bson.ObjectID { return s.ID },
+		imdbID:  func(s *models.TVShow) string { return s.ImdbID },
+		version: tvShowVersion,
+		check:   checkSeasons,
+	}
+)
+
+func (app *App) ImportMovies() gin.HandlerFunc {
+	return app.importHandler(models.ContentTypeMovie)
+}
+
+func (app *App) ImportTVShows() gin.HandlerFunc {
+	return app.importHandler(models.ContentTypeTVShow)
+}
+
+// importHandler imports the CSV or NDJSON body, as told by the format query
+// or else the Content-Type, and answers the report of every row.
+func (app *App) importHandler(contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		format, body, ok := readUpload(c, importFormats, "text/csv or application/x-ndjson")
+		if !ok {
+			return
+		}
+
+		userId, _ := utils.GetUserIdFromContext(c)
+		report, err := app.ImportCatalog(c.Request.Context(), contentType, bytes.NewReader(body), format, userId)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input: "+err.Error()).Wrap(err))
+			return
+		}
+		auditEntry(c).Detail = fmt.Sprintf("created %d, updated %d, failed %d",
+			report.Created, report.Updated, report.Failed)
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// ImportCatalog upserts by imdb_id the movies or TV shows read from r in
+// the given format, recording userId as the author of the revisions. Every
+// row is validated and written on its own, so a failing row does not stop
+// the others; the error returned is about the input as a whole.
+func (app *App) ImportCatalog(ctx context.Context, contentType string, r io.Reader,
+	format, userId string) (models.ImportReport, error) {
+
+	switch contentType {
+	case models.ContentTypeMovie:
+		return importTitles(ctx, app, app.Repos.Movies, movieKind, r, format, userId)
+	case models.ContentTypeTVShow:
+		return importTitles(ctx, app, app.Repos.TVShows, tvShowKind, r, format, userId)
+	default:
+		return models.ImportReport{}, errors.New("content type must be movie or tv_show")
+	}
+}
+
+func importTitles[T any](ctx context.Context, app *App, titles repository.TitleRepository[T],
+	kind titleKind[T], r io.Reader, format, userId string) (models.ImportReport, error) {
+
+	report := models.ImportReport{Rows: []models.ImportRow{}}
+	err := utils.DecodeRecords(r, format, func(line int, title T, err error) {
+		row := models.ImportRow{Row: line, ImdbID: kind.imdbID(&title)}
+		if err != nil {
+			row.Status, row.Reason = models.ImportFailed, "Invalid input: "+err.Error()
+		} else {
+			row.Status, row.Reason = importTitle(ctx, app, titles, kind, title, userId)
+		}
+		addImportRow(&report, row)
+	})
+
+	return report, err
+}
+
+// importTitle creates or replaces one title and returns the status of its
+// row, with the reason when it failed.
+func importTitle[T any](ctx context.Context, app *App, titles repository.TitleRepository[T],
+	kind titleKind[T], title T, userId string) (string, string) {
+
+	if reason := checkTitle(kind, &title); reason != "" {
+		return models.ImportFailed, reason
+	}
+	imdbID := kind.imdbID(&title)
+
+	ctx, cancel := context.WithTimeout(ctx, app.Config.MongoOperationTimeout)
+	defer cancel()
+
+	status, action := models.ImportUpdated, models.RevisionUpdate
+	var before *T
+	current, err := titles.FindByImdbID(ctx, imdbID)
+	switch {
+	case err == nil:
+		before = &current
+		_, err = titles.Replace(ctx, imdbID, title, *kind.version(&current))
+	case errors.Is(err, repository.ErrNotFound):
+		status, action = models.ImportCreated, models.RevisionCreate
+		_, err = titles.Insert(ctx, title)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrVersionConflict):
+			return models.ImportFailed, "The " + kind.name + " was changed during the import"
+		case errors.Is(err, repository.ErrInTrash):
+			return models.ImportFailed, "The " + kind.name + " is in the trash, restore it first"
+		case errors.Is(err, repository.ErrIDTaken):
+			return models.ImportFailed, "The _id belongs to another " + kind.name
+		case errors.Is(err, repository.ErrDuplicate):
+			return models.ImportFailed, "The " + kind.name + " was added during the import"
+		}
+		slog.ErrorContext(ctx, "Error importing title", "imdb_id", imdbID, "error", err)
+		return models.ImportFailed, "Failed to save the " + kind.name
+	}
+
+	if err := saveRevision(ctx, app, titles, imdbID, action, userId, before); err != nil {
+		slog.ErrorContext(ctx, "Error recording revision", "action", action, "imdb_id", imdbID, "error", err)
+	}
+	return status, ""
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+// readUpload reads the body of an upload, in one of the formats the
+// Content-Type maps to unless the format query names one. Otherwise it
+// responds with the error and returns false.
+func readUpload(c *gin.Context, formats map[string]string, accepted string) (string, []byte, bool) {
+	format := c.Query("format")
+	if format == "" {
+		format = formats[c.ContentType()]
+	}
+	if format == "" {
+		utils.RespondError(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia,
+			"Content-Type must be "+accepted))
+		return "", nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.RespondError(c, apierror.New(http.StatusRequestEntityTooLarge, apierror.CodeInvalidInput,
+				fmt.Sprintf("An upload is limited to %d MB", maxImportBytes>>20)))
+			return "", nil, false
+		}
+		utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+		return "", nil, false
+	}
+
+	return format, body, true
+}
+
+// checkTitle validates the title and applies the rules of its kind,
+// returning why it is invalid or "".
+func checkTitle[T any](kind titleKind[T], title *T) string {
+	if err := validate.Struct(title); err != nil {
+		return validationReason(err)
+	}
+	if kind.check != nil {
+		if apiErr := kind.check(title); apiErr != nil {
+			return apiErr.Message
+		}
+	}
+	return ""
+}
+
+func addImportRow(report *models.ImportReport, row models.ImportRow) {
+	switch row.Status {
+	case models.ImportCreated:
+		report.Created++
+	case models.ImportUpdated:
+		report.Updated++
+	default:
+		report.Failed++
+	}
+	report.Rows = append(report.Rows, row)
+}
+
+// validationReason lists the fields that failed validation and why.
+func validationReason(err error) string {
+	apiErr := apierror.Validation("Validation failed", err)
+	if len(apiErr.Fields) == 0 {
+		return apiErr.Message
+	}
+
+	reasons := make([]string, 0, len(apiErr.Fields))
+	for _, field := range apiErr.Fields {
+		reasons = append(reasons, field.Field+" "+field.Message)
+	}
+	return apiErr.Message + ": " + strings.Join(reasons, ", ")
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupImportRouter() (*gin.Engine, *App) {
+	router, app := setupTestRouter()
+	router.POST("/import/movies", asAdmin, app.ImportMovies())
+	router.POST("/import/tv_shows", asAdmin, app.ImportTVShows())
+	return router, app
+}
+
+func importRequest(router *gin.Engine, path, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportMovies_CSV(t *testing.T) {
+	router, app := setupImportRouter()
+	ctx := t.Context()
+	_, err := app.Repos.Movies.Insert(ctx, testMovie("tt0000001", "Old Title"))
+	assert.NoError(t, err)
+
+	body := "imdb_id,title,poster_path,youtube_id,genre,ranking\n" +
+		`tt0000001,1917,https://example.com/a.jpg,abc,"[{""genre_id"":1,""genre_name"":""War""}]","{""ranking_value"":2,""ranking_name"":""Good""}"` + "\n" +
+		`tt0000002,New Movie,https://example.com/b.jpg,def,"[{""genre_id"":1,""genre_name"":""War""}]","{""ranking_value"":1,""ranking_name"":""Excellent""}"` + "\n" +
+		`tt0000003,Broken,not-a-url,ghi,,` + "\n"
+
+	w := importRequest(router, "/import/movies", "text/csv", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report models.ImportReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, models.ImportRow{Row: 2, ImdbID: "tt0000001", Status: models.ImportUpdated}, report.Rows[0])
+	assert.Equal(t, models.ImportRow{Row: 3, ImdbID: "tt0000002", Status: models.ImportCreated}, report.Rows[1])
+	assert.Equal(t, 4, report.Rows[2].Row)
+	assert.Equal(t, "Validation failed: poster_path must be a valid URL, genre is required, "+
+		"ranking.ranking_value is required, ranking.ranking_name is required",
+		report.Rows[2].Reason)
+
+	movie, err := app.Repos.Movies.FindByImdbID(ctx, "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "1917", movie.Title)
+	assert.Equal(t, int64(2), movie.Version)
+
+	revisions, err := app.Repos.Revisions.FindByTitle(ctx, models.ContentTypeMovie, "tt0000002")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 1)
+	assert.Equal(t, "admin-1", revisions[0].UserID)
+}
+
+func TestImportTVShows_NDJSON(t *testing.T) {
+	router, app := setupImportRouter()
+
+	tvShow := models.TVShow{
+		ImdbID:     "tt1000001",
+		Title:      "Test Show",
+		PosterPath: "https://example.com/poster.jpg",
+		TrailerID:  "abc",
+		Genre:      []models.Genre{{GenreID: 1, GenreName: "Drama"}},
+		Ranking:    models.Ranking{RankingValue: 2, RankingName: "Good"},
+		Seasons: []models.Season{{SeasonNumber: 1, Episodes: []models.Episode{
+			{EpisodeNumber: 1, EpisodeTitle: "Pilot", Duration: 45, AirDate: models.NewDate(2024, 1, 1)},
+		}}},
+		TotalSeasons: 1,
+		Status:       "Ongoing",
+		FirstAired:   models.NewDate(2024, 1, 1),
+	}
+	valid, _ := json.Marshal(tvShow)
+	tvShow.ImdbID, tvShow.TotalSeasons = "tt1000002", 2
+	mismatched, _ := json.Marshal(tvShow)
+	body := strings.Join([]string{string(valid), "", string(mismatched), `{"imdb_id": 7}`}, "\n")
+
+	w := importRequest(router, "/import/tv_shows", "application/x-ndjson", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report models.ImportReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 3, report.Rows[1].Row)
+	assert.Equal(t, "Total seasons doesn't match the number of seasons provided", report.Rows[1].Reason)
+	assert.Equal(t, 4, report.Rows[2].Row)
+	assert.Contains(t, report.Rows[2].Reason, "Invalid input")
+
+	imported, err := app.Repos.TVShows.FindByImdbID(t.Context(), "tt1000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Pilot", imported.Seasons[0].Episodes[0].EpisodeTitle)
+}
+
+func TestImportMovies_Rejected(t *testing.T) {
+	router, _ := setupImportRouter()
+
+	w := importRequest(router, "/import/movies", "application/xml", "<movies/>")
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = importRequest(router, "/import/movies", "text/csv", "imdb_id,name\ntt0000001,Movie\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unknown column \"name\"`)
+}
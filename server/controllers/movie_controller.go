@@ -1,188 +1,227 @@
 package controllers
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
-	"io"
-	"log"
 	"net/http"
-	"os"
+	"reflect"
+	"server/apierror"
+	"server/metrics"
 	"server/utils"
-	"strconv"
 	"strings"
-	"time"
 
-	"server/database"
 	"server/models"
+	"server/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/joho/godotenv"
-	"github.com/tmc/langchaingo/llms/openai"
-	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-var movieCollection *mongo.Collection = database.OpenCollection("movies")
-var rankingCollection *mongo.Collection = database.OpenCollection("rankings")
-var genreCollection *mongo.Collection = database.OpenCollection("genres")
-var validate = validator.New()
+var validate = newValidator()
 
-func GetMovies() gin.HandlerFunc {
+func (app *App) GetMovies() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		between, err := parseDateRange(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
-		var movies []models.Movie
 
-		cursor, err := movieCollection.Find(ctx, bson.M{})
+		movies, err := app.Repos.Movies.FindBetween(ctx, between)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch movies"})
-		}
-		defer cursor.Close(ctx)
-		if err = cursor.All(ctx, &movies); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to decode movies"})
+			utils.RespondError(c, apierror.Internal("Failed to fetch movies", err))
+			return
 		}
 
 		c.JSON(http.StatusOK, movies)
 	}
 }
 
-func GetMovie() gin.HandlerFunc {
+func (app *App) GetMovie() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		movieID := c.Param("imdb_id")
 		if movieID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Movie ID is required"})
+			utils.RespondError(c, apierror.BadRequest("Movie ID is required"))
 			return
 		}
 
-		var movie models.Movie
-		err := movieCollection.FindOne(ctx, bson.M{"imdb_id": movieID}).Decode(&movie)
+		movie, err := app.Repos.Movies.FindByImdbID(ctx, movieID)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Movie not found"})
+			if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrInTrash) {
+				utils.RespondError(c, apierror.NotFound("Movie not found"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to fetch movie", err))
 			return
 		}
 
-		c.JSON(http.StatusOK, movie)
+		app.recordTitleEvent(c, movie.ImdbID, models.ContentTypeMovie, models.TitleEventView)
+
+		respondWithETag(c, movie.Version, movie)
 	}
 }
 
-func AddMovie() gin.HandlerFunc {
+func (app *App) AddMovie() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		var movie models.Movie
 		if err := c.ShouldBindJSON(&movie); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid input"})
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
 			return
 		}
+		auditEntry(c).ImdbID = movie.ImdbID
 		if err := validate.Struct(movie); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Validation failed", "details": err.Error()})
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
 			return
 		}
 
-		var existingMovie models.Movie
-		err := movieCollection.FindOne(ctx, bson.M{"title": movie.Title}).Decode(&existingMovie)
-		if err == nil {
-			c.JSON(http.StatusConflict, gin.H{"Error": "A movie with this title already exists"})
+		exists, err := app.Repos.Movies.ExistsWithTitle(ctx, movie.Title)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to check movie", err))
+			return
+		}
+		if exists {
+			utils.RespondError(c, apierror.Conflict("A movie with this title already exists"))
 			return
 		}
 
-		result, err := movieCollection.InsertOne(ctx, movie)
+		insertedID, err := app.Repos.Movies.Insert(ctx, movie)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Operation to add a movie failed"})
+			if errors.Is(err, repository.ErrInTrash) {
+				utils.RespondError(c, apierror.Conflict("A movie with this IMDB ID is in the trash, restore it instead"))
+				return
+			}
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict("A movie with this IMDB ID already exists"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Operation to add a movie failed", err))
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		recordRevision(app, c, app.Repos.Movies, movie.ImdbID, models.RevisionCreate, nil)
+
+		c.JSON(http.StatusCreated, gin.H{"InsertedID": insertedID})
 	}
 }
 
-func UpdateMovie() gin.HandlerFunc {
+func (app *App) UpdateMovie() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		movieID := c.Param("imdb_id")
 		if movieID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Movie ID is required"})
+			utils.RespondError(c, apierror.BadRequest("Movie ID is required"))
 			return
 		}
 
 		var movie models.Movie
 		if err := c.ShouldBindJSON(&movie); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid input"})
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if movie.ImdbID != "" && movie.ImdbID != movieID {
+			utils.RespondError(c, apierror.New(http.StatusBadRequest, apierror.CodeImmutableField,
+				"imdb_id cannot be changed"))
 			return
 		}
 
-		result, err := movieCollection.ReplaceOne(
-			ctx,
-			bson.M{"imdb_id": movieID},
-			movie,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update movie"})
+		if err := validate.Struct(movie); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
 			return
 		}
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Movie not found"})
+
+		before, ok := findForWrite(ctx, c, app.Repos.Movies, movieID, "Movie", movieVersion)
+		if !ok {
 			return
 		}
 
+		modifiedCount, err := app.Repos.Movies.Replace(ctx, movieID, movie, before.Version)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("Movie not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict("Movie"))
+				return
+			}
+			if errors.Is(err, repository.ErrInTrash) {
+				utils.RespondError(c, apierror.Conflict("A movie with this IMDB ID is in the trash, restore it instead"))
+				return
+			}
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict("A movie with this IMDB ID already exists"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to update movie", err))
+			return
+		}
+
+		recordRevision(app, c, app.Repos.Movies, movieID, models.RevisionUpdate, &before)
+
 		c.JSON(http.StatusOK, gin.H{
 			"Message":        "Movie updated successfully",
-			"modified_count": result.ModifiedCount,
+			"modified_count": modifiedCount,
 		})
 	}
 }
 
-func DeleteMovie() gin.HandlerFunc {
+func (app *App) DeleteMovie() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		movieID := c.Param("imdb_id")
 		if movieID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Movie ID is required"})
+			utils.RespondError(c, apierror.BadRequest("Movie ID is required"))
 			return
 		}
 
-		result, err := movieCollection.DeleteOne(ctx, bson.M{"imdb_id": movieID})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete the movie"})
+		before, ok := findForWrite(ctx, c, app.Repos.Movies, movieID, "Movie", movieVersion)
+		if !ok {
 			return
 		}
-		if result.DeletedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Movie not found"})
+
+		userId, _ := utils.GetUserIdFromContext(c)
+		err := app.Repos.Movies.Delete(ctx, movieID, userId, before.Version)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("Movie not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict("Movie"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to delete the movie", err))
 			return
 		}
 
+		recordRevision(app, c, app.Repos.Movies, movieID, models.RevisionDelete, &before)
+
 		c.JSON(http.StatusOK, gin.H{"Message": "Movie deleted successfully"})
 	}
 }
 
-func AdminReviewUpdate() gin.HandlerFunc {
+func (app *App) AdminReviewUpdate() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, err := utils.GetRoleFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Role not found in context"})
-			return
-		}
-		if role != "ADMIN" {
-			c.JSON(http.StatusUnauthorized, gin.H{"Error": "User is not an ADMIN"})
+		if !requireAdmin(c) {
 			return
 		}
 
 		movieId := c.Param("imdb_id")
 		if movieId == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Movie Id required"})
+			utils.RespondError(c, apierror.BadRequest("Movie Id required"))
 			return
 		}
 
@@ -190,40 +229,43 @@ func AdminReviewUpdate() gin.HandlerFunc {
 			AdminReview string `json:"admin_review"`
 		}
 		if err := c.ShouldBind(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+			utils.RespondError(c, apierror.BadRequest("Invalid request body").Wrap(err))
 			return
 		}
 
-		sentiment, rankVal, err := getReviewRankingWithHugging(req.AdminReview)
+		sentiment, rankVal, err := app.classifyReview(c, req.AdminReview)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error getting review ranking"})
+			utils.RespondError(c, apierror.Internal("Error getting review ranking", err))
 			return
 		}
 
-		filter := bson.M{"imdb_id": movieId}
-		update := bson.M{
-			"$set": bson.M{
-				"admin_review": req.AdminReview,
-				"ranking": bson.M{
-					"ranking_value": rankVal,
-					"ranking_name":  sentiment,
-				},
-			},
-		}
-
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
-		result, err := movieCollection.UpdateOne(ctx, filter, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error updating movie"})
+		before, ok := findForWrite(ctx, c, app.Repos.Movies, movieId, "Movie", movieVersion)
+		if !ok {
 			return
 		}
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Movie not found"})
+
+		err = app.Repos.Movies.UpdateReview(ctx, movieId, req.AdminReview, models.Ranking{
+			RankingValue: rankVal,
+			RankingName:  sentiment,
+		}, before.Version)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("Movie not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict("Movie"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Error updating movie", err))
 			return
 		}
 
+		recordRevision(app, c, app.Repos.Movies, movieId, models.RevisionReview, &before)
+
 		var response struct {
 			RankingName string `json:"ranking_name"`
 			AdminReview string `json:"admin_review"`
@@ -235,115 +277,37 @@ func AdminReviewUpdate() gin.HandlerFunc {
 	}
 }
 
-func GetUsersFavouriteGenres(userId string) ([]string, error) {
-	ctx, cancel := getDBContext()
-	defer cancel()
-
-	filter := bson.M{"user_id": userId}
-
-	projection := bson.M{
-		"favourite_genres.genre_name": 1,
-		"_id":                         0,
-	}
-
-	opts := options.FindOne().SetProjection(projection)
-
-	var result bson.M
-	err := usersCollection.FindOne(ctx, filter, opts).Decode(&result)
+// GetUsersFavouriteGenres returns the names of the user's favourite genres,
+// an empty slice when the user has none or does not exist.
+func GetUsersFavouriteGenres(ctx context.Context, users repository.UserRepository, userId string) ([]string, error) {
+	favouriteGenres, err := users.FavouriteGenres(ctx, userId)
 	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
+		if errors.Is(err, repository.ErrNotFound) {
 			return []string{}, nil
 		}
+		return nil, err
 	}
 
-	favGenres, ok := result["favourite_genres"].(bson.A)
-	if !ok {
-		return []string{}, errors.New("unable to retrieve favourite genres for user")
-	}
-	var genreNames []string
-	for _, item := range favGenres {
-		if genreMap, ok := item.(bson.D); ok {
-			for _, elems := range genreMap {
-				if elems.Key == "genre_name" {
-					if name, ok := elems.Value.(string); ok {
-						genreNames = append(genreNames, name)
-					}
-				}
-			}
-		}
+	genreNames := make([]string, 0, len(favouriteGenres))
+	for _, genre := range favouriteGenres {
+		genreNames = append(genreNames, genre.GenreName)
 	}
 
 	return genreNames, nil
 }
 
-func GetRecommendedMovies() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		userId, err := utils.GetUserIdFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "User Id not found"})
-			return
-		}
-
-		favouriteGenres, err := GetUsersFavouriteGenres(userId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-			return
-		}
-
-		// for development
-		err = godotenv.Load(".env")
-		if err != nil {
-			log.Println("Warning: .env not found")
-		}
-
-		var recommendedMovieLimitVal int64 = 5
-		recommendedMovieLimitStr := os.Getenv("RECOMMENDED_MOVIE_LIMIT")
-		if recommendedMovieLimitStr != "" {
-			recommendedMovieLimitVal, _ = strconv.ParseInt(recommendedMovieLimitStr, 10, 64)
-		}
-
-		findOptions := options.Find()
-		findOptions.SetSort(bson.D{{Key: "ranking.ranking_value", Value: 1}})
-		findOptions.SetLimit(recommendedMovieLimitVal)
-
-		filter := bson.M{"genre.genre_name": bson.M{"$in": favouriteGenres}}
-
-		ctx, cancel := getDBContext()
-		defer cancel()
-
-		cursor, err := movieCollection.Find(ctx, filter, findOptions)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error fetching recommended movies"})
-			return
-		}
-		defer cursor.Close(ctx)
-
-		var recommendedMovies []models.Movie
-		if err := cursor.All(ctx, &recommendedMovies); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
-			return
-		}
-
-		c.JSON(http.StatusOK, recommendedMovies)
-	}
+func (app *App) GetRecommendedMovies() gin.HandlerFunc {
+	return recommendationHandler[models.Movie](app, app.Repos.Movies, app.Config.Recommendations.MovieLimit, "movies")
 }
 
-func GetGenres() gin.HandlerFunc {
+func (app *App) GetGenres() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
-		var genres []models.Genre
-
-		cursor, err := genreCollection.Find(ctx, bson.M{})
+		genres, err := app.Repos.Genres.FindAll(ctx)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error fetching movie genres"})
-			return
-		}
-		defer cursor.Close(ctx)
-
-		if err := cursor.All(ctx, &genres); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			utils.RespondError(c, apierror.Internal("Error fetching movie genres", err))
 			return
 		}
 
@@ -354,187 +318,60 @@ func GetGenres() gin.HandlerFunc {
 // Utility functions
 // ---------------------------------------------------------------------------------------
 
-const dbTimeout = 100 * time.Second
-
-func getDBContext() (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), dbTimeout)
-}
-
-func getReviewRankingWithHugging(adminReview string) (string, int, error) {
-	rankings, err := getRankings()
-	if err != nil {
-		return "", 0, err
-	}
-
-	sentiment := ""
-	for _, ranking := range rankings {
-		if ranking.RankingValue != 999 {
-			sentiment = sentiment + ranking.RankingName + ","
-		}
-	}
-	sentiment = strings.Trim(sentiment, ",")
-
-	// test development
-	err = godotenv.Load(".env")
-	if err != nil {
-		log.Println("Warning: .env file not found")
-	}
-
-	basePromptTemplate := os.Getenv("BASE_PROMPT_TEMPLATE")
-	basePrompt := strings.Replace(basePromptTemplate, "{rankings}", sentiment, 1)
-	fullPrompt := basePrompt + adminReview
-
-	var response string
-
-	hfToken := os.Getenv("HUGGING_FACE_HUB_TOKEN")
-	hfModel := os.Getenv("HF_MODEL")
-
-	if hfToken == "" {
-		return "", 0, errors.New("HUGGING_FACE_HUB_TOKEN not set")
-	}
-	if hfModel == "" {
-		return "", 0, errors.New("HF_MODEL not set")
-	}
-
-	response, err = callHuggingFaceAPI(hfToken, hfModel, fullPrompt)
+// requireAdmin responds 403 and returns false unless the authenticated user
+// is an admin.
+func requireAdmin(c *gin.Context) bool {
+	role, err := utils.GetRoleFromContext(c)
 	if err != nil {
-		return "", 0, err
+		utils.RespondError(c, apierror.BadRequest("Role not found in context").Wrap(err))
+		return false
 	}
-
-	rankVal := 0
-	for _, ranking := range rankings {
-		if ranking.RankingName == response {
-			rankVal = ranking.RankingValue
-			break
-		}
+	if role != "ADMIN" {
+		metrics.AuthFailures.WithLabelValues(metrics.AuthForbidden).Inc()
+		utils.RespondError(c, apierror.Forbidden("User is not an ADMIN"))
+		return false
 	}
-
-	return response, rankVal, nil
+	return true
 }
 
-func callHuggingFaceAPI(token, model, prompt string) (string, error) {
-	url := fmt.Sprintf("https://api-inference.huggingface.co/models/%s", model)
-
-	payload := map[string]interface{}{
-		"inputs": prompt,
-		"parameters": map[string]interface{}{
-			"max_new_tokens": 50,
-			"temperature":    0.1,
-		},
-	}
-
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return "", err
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer func() {
-		if closeErr := resp.Body.Close(); closeErr != nil {
-			log.Printf("Error closing response body: %v", closeErr)
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("HuggingFace API error: %s - %s", resp.Status, string(body))
-	}
-
-	var result []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
-	}
-
-	if len(result) == 0 {
-		return "", errors.New("empty response from HuggingFace")
-	}
-
-	generatedText, ok := result[0]["generated_text"].(string)
-	if !ok {
-		return "", errors.New("invalid response format from HuggingFace")
-	}
-
-	generatedText = strings.TrimPrefix(generatedText, prompt)
-	generatedText = strings.TrimSpace(generatedText)
-
-	return generatedText, nil
+// dbContext bounds the database work of a request by the configured
+// operation timeout. It derives from the request context, so the work stops
+// when the client goes away.
+func (app *App) dbContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), app.Config.MongoOperationTimeout)
 }
 
-func getReviewRankingOpenAi(adminReview string) (string, int, error) {
-	rankings, err := getRankings()
-	if err != nil {
-		return "", 0, err
-	}
-
-	sentiment := ""
-	for _, ranking := range rankings {
-		if ranking.RankingValue != 999 {
-			sentiment = sentiment + ranking.RankingName + ","
-		}
-	}
-	sentiment = strings.Trim(sentiment, ",")
-
-	// Only for testing development
-	err = godotenv.Load(".env")
-	if err != nil {
-		log.Println("Warning: .env file not found")
-	}
-
-	OpenAiApiKey := os.Getenv("OPENAI_API_KEY")
-	if OpenAiApiKey == "" {
-		return "", 0, errors.New("could not read OpenAI Key")
-	}
-
-	llm, err := openai.New(openai.WithToken(OpenAiApiKey))
-	if err != nil {
-		return "", 0, err
-	}
-
-	basePromptTemplate := os.Getenv("BASE_PROMPT_TEMPLATE")
-	basePrompt := strings.Replace(basePromptTemplate, "{rankings}", sentiment, 1)
-
-	response, err := llm.Call(context.Background(), basePrompt+adminReview)
-	if err != nil {
-		return "", 0, err
-	}
-
-	rankVal := 0
-	for _, ranking := range rankings {
-		if ranking.RankingName == response {
-			rankVal = ranking.RankingValue
-			break
-		}
-	}
-
-	return response, rankVal, nil
+// newValidator returns a validator naming fields after their JSON key, as the
+// field errors of the responses do, and checking dates as the times they
+// hold.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	v.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(models.Date).Time
+	}, models.Date{})
+	return v
 }
 
-func getRankings() ([]models.Ranking, error) {
-	ctx, cancel := getDBContext()
+// classifyReview asks the classifier which of the stored rankings the admin
+// review expresses, giving the provider the configured classifier timeout.
+func (app *App) classifyReview(c *gin.Context, adminReview string) (string, int, error) {
+	dbCtx, cancel := app.dbContext(c)
 	defer cancel()
 
-	cursor, err := rankingCollection.Find(ctx, bson.M{})
+	rankings, err := app.Repos.Rankings.FindAll(dbCtx)
 	if err != nil {
-		return nil, err
+		return "", 0, err
 	}
-	defer cursor.Close(ctx)
 
-	var rankings []models.Ranking
-	if err := cursor.All(ctx, &rankings); err != nil {
-		return nil, err
-	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), app.Config.Classifier.Timeout)
+	defer cancel()
 
-	return rankings, nil
+	return app.Classifier.Classify(ctx, adminReview, rankings)
 }
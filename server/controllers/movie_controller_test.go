@@ -0,0 +1,384 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMovie(imdbID, title string) models.Movie {
+	return models.Movie{
+		ImdbID:     imdbID,
+		Title:      title,
+		PosterPath: "https://example.com/poster.jpg",
+		YoutubeID:  "dQw4w9WgXcQ",
+		Genre:      []models.Genre{{GenreID: 1, GenreName: "Action"}},
+		Ranking:    models.Ranking{RankingValue: 2, RankingName: "Good"},
+	}
+}
+
+func TestAddMovie_Success(t *testing.T) {
+	router, app := setupTestRouter()
+	router.POST("/add_movie", app.AddMovie())
+
+	jsonData, _ := json.Marshal(testMovie("tt0000001", "Test Movie"))
+	req, _ := http.NewRequest("POST", "/add_movie", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	ctx := t.Context()
+
+	movie, err := app.Repos.Movies.FindByImdbID(ctx, "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Movie", movie.Title)
+	assert.False(t, movie.ID.IsZero())
+}
+
+func TestAddMovie_DuplicateTitle(t *testing.T) {
+	router, app := setupTestRouter()
+	router.POST("/add_movie", app.AddMovie())
+
+	ctx := t.Context()
+	_, err := app.Repos.Movies.Insert(ctx, testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	jsonData, _ := json.Marshal(testMovie("tt0000002", "Test Movie"))
+	req, _ := http.NewRequest("POST", "/add_movie", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestAddMovie_DuplicateImdbID(t *testing.T) {
+	router, app := setupTestRouter()
+	router.POST("/add_movie", app.AddMovie())
+
+	_, err := app.Repos.Movies.Insert(t.Context(), testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	jsonData, _ := json.Marshal(testMovie("tt0000001", "Another Movie"))
+	req, _ := http.NewRequest("POST", "/add_movie", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestGetMovies_ReleaseDates(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/movies", app.GetMovies())
+
+	for i, released := range []string{"2023-06-01", "2024-01-31", "2024-12-31", ""} {
+		movie := testMovie(fmt.Sprintf("tt000000%d", i), fmt.Sprintf("Movie %d", i))
+		if released != "" {
+			movie.ReleaseDate.Time, _ = time.Parse(time.DateOnly, released)
+		}
+		_, err := app.Repos.Movies.Insert(t.Context(), movie)
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		query  string
+		status int
+		movies []string
+	}{
+		{"", http.StatusOK, []string{"tt0000000", "tt0000001", "tt0000002", "tt0000003"}},
+		{"?from=2024-01-31", http.StatusOK, []string{"tt0000001", "tt0000002"}},
+		{"?from=2024-01-01&to=2024-01-31T00:00:00Z", http.StatusOK, []string{"tt0000001"}},
+		{"?to=2023-12-31", http.StatusOK, []string{"tt0000000"}},
+		{"?from=2025-01-01", http.StatusOK, []string{}},
+		{"?from=01/01/2024", http.StatusBadRequest, nil},
+		{"?from=2024-12-31&to=2024-01-01", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/movies"+tt.query, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.movies == nil {
+				return
+			}
+
+			var movies []models.Movie
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &movies))
+			imdbIDs := []string{}
+			for _, movie := range movies {
+				imdbIDs = append(imdbIDs, movie.ImdbID)
+			}
+			assert.Equal(t, tt.movies, imdbIDs)
+		})
+	}
+}
+
+func TestGetMovie_NotFound(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/movie/:imdb_id", app.GetMovie())
+
+	req, _ := http.NewRequest("GET", "/movie/tt9999999", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var response apierror.Response
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		return
+	}
+	assert.Equal(t, "Movie not found", response.Error.Message)
+}
+
+// unavailableMovies is a movie repository whose lookups by IMDB ID fail as
+// they do when MongoDB is down.
+type unavailableMovies struct {
+	repository.MovieRepository
+}
+
+func (unavailableMovies) FindByImdbID(context.Context, string) (models.Movie, error) {
+	return models.Movie{}, errors.New("server selection timeout")
+}
+
+func TestGetMovie_Unavailable(t *testing.T) {
+	router, app := setupTestRouter()
+	app.Repos.Movies = unavailableMovies{app.Repos.Movies}
+	router.GET("/movie/:imdb_id", app.GetMovie())
+
+	req, _ := http.NewRequest("GET", "/movie/tt0000001", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestUpdateAndDeleteMovie(t *testing.T) {
+	router, app := setupTestRouter()
+	router.PUT("/update_movie/:imdb_id", app.UpdateMovie())
+	router.DELETE("/delete_movie/:imdb_id", app.DeleteMovie())
+
+	ctx := t.Context()
+	_, err := app.Repos.Movies.Insert(ctx, testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	jsonData, _ := json.Marshal(testMovie("tt0000001", "Renamed Movie"))
+	updateReq, _ := http.NewRequest("PUT", "/update_movie/tt0000001", bytes.NewBuffer(jsonData))
+	updateReq.Header.Set("Content-Type", "application/json")
+	updateW := httptest.NewRecorder()
+	router.ServeHTTP(updateW, updateReq)
+
+	assert.Equal(t, http.StatusOK, updateW.Code)
+	movie, err := app.Repos.Movies.FindByImdbID(ctx, "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed Movie", movie.Title)
+
+	// The IMDB ID of the body must be the one of the URL
+	jsonData, _ = json.Marshal(testMovie("tt0000002", "Moved Movie"))
+	updateReq, _ = http.NewRequest("PUT", "/update_movie/tt0000001", bytes.NewBuffer(jsonData))
+	updateReq.Header.Set("Content-Type", "application/json")
+	updateW = httptest.NewRecorder()
+	router.ServeHTTP(updateW, updateReq)
+	assert.Equal(t, http.StatusBadRequest, updateW.Code)
+	assert.Contains(t, updateW.Body.String(), apierror.CodeImmutableField)
+	_, err = app.Repos.Movies.FindByImdbID(ctx, "tt0000002")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	deleteReq, _ := http.NewRequest("DELETE", "/delete_movie/tt0000001", nil)
+	deleteW := httptest.NewRecorder()
+	router.ServeHTTP(deleteW, deleteReq)
+	assert.Equal(t, http.StatusOK, deleteW.Code)
+
+	deleteW = httptest.NewRecorder()
+	router.ServeHTTP(deleteW, deleteReq)
+	assert.Equal(t, http.StatusNotFound, deleteW.Code)
+}
+
+func TestAdminReviewUpdate_ClassifierTimeout(t *testing.T) {
+	router, app := setupTestRouter()
+	app.Classifier = slowClassifier{}
+	app.Config.Classifier.Timeout = 10 * time.Millisecond
+	router.PATCH("/update_review/:imdb_id", func(c *gin.Context) {
+		c.Set("role", "ADMIN")
+	}, app.AdminReviewUpdate())
+
+	_, err := app.Repos.Movies.Insert(t.Context(), testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	body := bytes.NewBufferString(`{"admin_review": "A masterpiece"}`)
+	req, _ := http.NewRequest("PATCH", "/update_review/tt0000001", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	var response apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, apierror.CodeTimeout, response.Error.Code)
+}
+
+func patchMovie(router *gin.Engine, imdbID, patch string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PATCH", "/movie/"+imdbID, bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPatchMovie_MergesFields(t *testing.T) {
+	router, app := setupTestRouter()
+	router.PATCH("/movie/:imdb_id", app.PatchMovie())
+
+	original := testMovie("tt0000001", "Test Movie")
+	original.AdminReview = "A masterpiece"
+	_, err := app.Repos.Movies.Insert(t.Context(), original)
+	assert.NoError(t, err)
+
+	// Immutable fields may be sent with their current value, in any notation
+	w := patchMovie(router, "tt0000001", `{"title": "Renamed Movie", "imdb_id": "tt0000001", "version": 1.0}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	movie, err := app.Repos.Movies.FindByImdbID(t.Context(), "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed Movie", movie.Title)
+	assert.Equal(t, original.PosterPath, movie.PosterPath)
+	assert.Equal(t, "A masterpiece", movie.AdminReview)
+	assert.Equal(t, original.Ranking, movie.Ranking)
+}
+
+func TestPatchMovie_Rejected(t *testing.T) {
+	router, app := setupTestRouter()
+	router.PATCH("/movie/:imdb_id", app.PatchMovie())
+
+	_, err := app.Repos.Movies.Insert(t.Context(), testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		patch  string
+		status int
+		code   string
+	}{
+		{"immutable imdb_id", `{"imdb_id": "tt0000002"}`, http.StatusBadRequest, apierror.CodeImmutableField},
+		{"version of another type", `{"version": "1"}`, http.StatusBadRequest, apierror.CodeImmutableField},
+		{"ranking bypasses the classifier", `{"ranking": {"ranking_value": 1}}`, http.StatusBadRequest, apierror.CodeImmutableField},
+		{"merged document is invalid", `{"title": null}`, http.StatusBadRequest, apierror.CodeValidationFailed},
+		{"not an object", `[]`, http.StatusBadRequest, apierror.CodeInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := patchMovie(router, "tt0000001", tt.patch)
+			assert.Equal(t, tt.status, w.Code)
+
+			var response apierror.Response
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Error.Code)
+		})
+	}
+
+	movie, err := app.Repos.Movies.FindByImdbID(t.Context(), "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Movie", movie.Title)
+
+	w := patchMovie(router, "tt9999999", `{"title": "Missing"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMovieETags(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/movie/:imdb_id", app.GetMovie())
+	router.PUT("/update_movie/:imdb_id", app.UpdateMovie())
+	router.PATCH("/movie/:imdb_id", app.PatchMovie())
+	router.DELETE("/delete_movie/:imdb_id", app.DeleteMovie())
+
+	_, err := app.Repos.Movies.Insert(t.Context(), testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	request := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("GET", "/movie/tt0000001", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, `"1"`, etag)
+
+	w = request("GET", "/movie/tt0000001", "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = request("PATCH", "/movie/tt0000001", `{"title": "Renamed Movie"}`, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+	// Both writes below were prepared against the first version
+	jsonData, _ := json.Marshal(testMovie("tt0000001", "Stale Movie"))
+	w = request("PUT", "/update_movie/tt0000001", string(jsonData), map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	var response apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, apierror.CodePrecondition, response.Error.Code)
+
+	w = request("DELETE", "/delete_movie/tt0000001", "", map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	movie, err := app.Repos.Movies.FindByImdbID(t.Context(), "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed Movie", movie.Title)
+	assert.Equal(t, int64(2), movie.Version)
+
+	w = request("DELETE", "/delete_movie/tt0000001", "", map[string]string{"If-Match": `"2"`})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUpdateReview_StaleVersion(t *testing.T) {
+	movies := repository.NewMemoryMovieRepository()
+	ctx := t.Context()
+
+	_, err := movies.Insert(ctx, testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	ranking := models.Ranking{RankingValue: 1, RankingName: "Excellent"}
+	assert.NoError(t, movies.UpdateReview(ctx, "tt0000001", "First review", ranking, 1))
+
+	// A review read before the first one was written must not overwrite it
+	err = movies.UpdateReview(ctx, "tt0000001", "Stale review", ranking, 1)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	err = movies.UpdateReview(ctx, "tt0000002", "Missing review", ranking, 1)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	movie, err := movies.FindByImdbID(ctx, "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "First review", movie.AdminReview)
+	assert.Equal(t, int64(2), movie.Version)
+}
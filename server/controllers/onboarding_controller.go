@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOnboardingSample returns random titles covering as many genres as
+// possible, for a new user to rate. The limit query defaults to
+// ONBOARDING_SAMPLE_SIZE.
+func (app *App) GetOnboardingSample() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := parseLimitQuery(c, "limit", app.Config.Recommendations.OnboardingSampleSize)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		movies, err := app.Repos.Movies.Sample(ctx, limit)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to sample movies", err))
+			return
+		}
+		tvShows, err := app.Repos.TVShows.Sample(ctx, limit)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to sample TV shows", err))
+			return
+		}
+
+		sample := interleaveRecommendations(movies, tvShows, map[string]bool{})
+		if int64(len(sample)) > limit {
+			sample = sample[:limit]
+		}
+
+		c.JSON(http.StatusOK, sample)
+	}
+}
+
+// CompleteOnboarding stores the ratings given to the onboarding sample and
+// adds the genres of every liked title to the user's favourite genres.
+func (app *App) CompleteOnboarding() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		var req models.OnboardingRatings
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		var likedGenres []models.Genre
+		seenGenres := map[string]bool{}
+		for _, rating := range req.Ratings {
+			titles, err := app.Repos.Catalog(rating.ContentType).Summaries(ctx, []string{rating.ImdbID})
+			if err != nil {
+				utils.RespondError(c, apierror.Internal("Failed to fetch title", err))
+				return
+			}
+			if len(titles) == 0 {
+				utils.RespondError(c, apierror.NotFound("Title not found: "+rating.ImdbID))
+				return
+			}
+			title := titles[0]
+
+			if rating.Score < likedRatingScore {
+				continue
+			}
+			for _, genre := range title.Genre {
+				if !seenGenres[genre.GenreName] {
+					seenGenres[genre.GenreName] = true
+					likedGenres = append(likedGenres, genre)
+				}
+			}
+		}
+
+		for _, rating := range req.Ratings {
+			err := app.Repos.Ratings.Upsert(ctx, models.Rating{
+				UserID:      userId,
+				ImdbID:      rating.ImdbID,
+				ContentType: rating.ContentType,
+				Score:       rating.Score,
+			})
+			if err != nil {
+				utils.RespondError(c, apierror.Internal("Failed to save rating", err))
+				return
+			}
+			app.recordTitleEvent(c, rating.ImdbID, rating.ContentType, models.TitleEventRating)
+		}
+
+		favouriteGenres, err := app.Repos.Users.AddFavouriteGenres(ctx, userId, likedGenres)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("User not found"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to update favourite genres", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"Message":          "Onboarding completed successfully",
+			"favourite_genres": favouriteGenres,
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRecommendedMovies_TrendingFallback(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/recommended_movies", asUser, app.GetRecommendedMovies())
+	// The unranked titles tie, keep a second boundary from reordering them
+	app.Config.Recommendations.Weights.Recency = 0
+
+	ctx := t.Context()
+	for _, movie := range []models.Movie{
+		rankedMovie("tt0000001", 2, "Action"),
+		rankedMovie("tt0000002", 999, "Comedy"),
+		rankedMovie("tt0000003", 999, "Drama"),
+	} {
+		_, err := app.Repos.Movies.Insert(ctx, movie)
+		assert.NoError(t, err)
+	}
+
+	request := func() []string {
+		req, _ := http.NewRequest("GET", "/recommended_movies", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var movies []models.Movie
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &movies))
+		imdbIDs := []string{}
+		for _, movie := range movies {
+			imdbIDs = append(imdbIDs, movie.ImdbID)
+		}
+		return imdbIDs
+	}
+
+	// Without favourite genres nor a chart the best ranked titles come first
+	assert.Equal(t, []string{"tt0000001", "tt0000002", "tt0000003"}, request())
+
+	err := app.Repos.Charts.ReplaceAll(ctx, models.ChartWindowWeekly, models.ContentTypeMovie, []models.Chart{{
+		ChartID:     models.ChartID(models.ChartWindowWeekly, models.ContentTypeMovie, models.ChartAllGenres),
+		Window:      models.ChartWindowWeekly,
+		ContentType: models.ContentTypeMovie,
+		Genre:       models.ChartAllGenres,
+		Entries: []models.ChartEntry{
+			{Rank: 1, ImdbID: "tt0000003", Score: 10},
+			{Rank: 2, ImdbID: "tt0000002", Score: 2},
+		},
+	}})
+	assert.NoError(t, err)
+
+	// The top trending title outscores the ranked one, the second does not
+	assert.Equal(t, []string{"tt0000003", "tt0000001", "tt0000002"}, request())
+}
+
+func TestGetOnboardingSample(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/onboarding", asUser, app.GetOnboardingSample())
+
+	ctx := t.Context()
+	for _, movie := range []models.Movie{
+		rankedMovie("tt0000001", 1, "Action"),
+		rankedMovie("tt0000002", 2, "Action"),
+		rankedMovie("tt0000003", 3, "Action"),
+		rankedMovie("tt0000004", 4, "Comedy"),
+	} {
+		_, err := app.Repos.Movies.Insert(ctx, movie)
+		assert.NoError(t, err)
+	}
+	_, err := app.Repos.TVShows.Insert(ctx, testTVShow("tt1000001", "Show", 1))
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"?limit=2", 2},
+	} {
+		req, _ := http.NewRequest("GET", "/onboarding"+tt.query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var sample []models.RecommendationItem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &sample))
+		assert.Len(t, sample, tt.want)
+
+		// Every sampled title of a content type represents a genre not
+		// covered by the ones before it
+		covered := map[string]bool{}
+		for _, item := range sample {
+			key := item.ContentType + ":" + item.Genre[0].GenreName
+			assert.False(t, covered[key], "%s sampled twice", key)
+			covered[key] = true
+		}
+	}
+}
+
+func TestCompleteOnboarding(t *testing.T) {
+	router, app := setupTestRouter()
+	router.POST("/onboarding", asUser, app.CompleteOnboarding())
+
+	ctx := t.Context()
+	_, err := app.Repos.Users.Insert(ctx, models.User{UserID: "user-1", Email: "user@example.com"})
+	assert.NoError(t, err)
+	for _, movie := range []models.Movie{
+		rankedMovie("tt0000001", 1, "Action"),
+		rankedMovie("tt0000002", 2, "Comedy", "Drama"),
+	} {
+		_, err := app.Repos.Movies.Insert(ctx, movie)
+		assert.NoError(t, err)
+	}
+
+	request := func(ratings ...models.OnboardingRating) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.OnboardingRatings{Ratings: ratings})
+		req, _ := http.NewRequest("POST", "/onboarding", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(
+		models.OnboardingRating{ImdbID: "tt0000001", ContentType: models.ContentTypeMovie, Score: 2},
+		models.OnboardingRating{ImdbID: "tt0000002", ContentType: models.ContentTypeMovie, Score: 5},
+	)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	favourites, err := GetUsersFavouriteGenres(ctx, app.Repos.Users, "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Comedy", "Drama"}, favourites)
+
+	ratings, err := app.Repos.Ratings.FindByUser(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, ratings, 2)
+
+	w = request(models.OnboardingRating{ImdbID: "tt0000009", ContentType: models.ContentTypeMovie, Score: 5})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
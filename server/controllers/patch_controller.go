@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+// immutableFields cannot be changed by a patch; sending their current value
+// is accepted.
+var immutableFields = []string{"_id", "imdb_id", "version", "deleted_at", "deleted_by"}
+
+// classifiedFields are only written by the admin review endpoints, which
+// rank the review with the classifier.
+var classifiedFields = []string{"admin_review", "ranking"}
+
+// PatchMovie applies a JSON merge patch (RFC 7396) to a movie.
+func (app *App) PatchMovie() gin.HandlerFunc {
+	return patchTitleHandler(app, app.Repos.Movies, "Movie", movieVersion, nil)
+}
+
+// PatchTVShow applies a JSON merge patch (RFC 7396) to a TV show.
+func (app *App) PatchTVShow() gin.HandlerFunc {
+	return patchTitleHandler(app, app.Repos.TVShows, "TV show", tvShowVersion, checkSeasons)
+}
+
+// patchTitleHandler merges the patch into the stored title, validates the
+// result as a whole and replaces the title with it, provided the title did
+// not change in between and matches the If-Match header when sent. check
+// adds the rules of the content type the struct tags cannot express and may
+// be nil.
+func patchTitleHandler[T any](app *App, titles repository.TitleRepository[T], kind string,
+	version func(*T) *int64, check func(*T) *apierror.Error) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
+			return
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+		if mediaType != mergePatchContentType && mediaType != gin.MIMEJSON {
+			utils.RespondError(c, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedMedia,
+				"Content-Type must be "+mergePatchContentType))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		patch, err := utils.ParseMergePatch(body)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid merge patch").Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		title, ok := findForWrite(ctx, c, titles, imdbID, kind, version)
+		if !ok {
+			return
+		}
+		currentVersion := *version(&title)
+
+		current, err := json.Marshal(title)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to patch "+kind, err))
+			return
+		}
+		if apiErr := checkPatchedFields(current, patch); apiErr != nil {
+			utils.RespondError(c, apiErr)
+			return
+		}
+
+		merged, err := utils.MergePatch(current, patch)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to patch "+kind, err))
+			return
+		}
+
+		var patched T
+		if err := json.Unmarshal(merged, &patched); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if err := validate.Struct(patched); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
+		}
+		if check != nil {
+			if apiErr := check(&patched); apiErr != nil {
+				utils.RespondError(c, apiErr)
+				return
+			}
+		}
+
+		if _, err := titles.Replace(ctx, imdbID, patched, currentVersion); err != nil {
+			if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrInTrash) {
+				utils.RespondError(c, apierror.NotFound(kind+" not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict(kind))
+				return
+			}
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict(kind+" conflicts with another title"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to update "+kind, err))
+			return
+		}
+
+		recordRevision(app, c, titles, imdbID, models.RevisionUpdate, &title)
+
+		*version(&patched) = currentVersion + 1
+		c.Header("ETag", utils.ETag(currentVersion+1))
+		c.JSON(http.StatusOK, patched)
+	}
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+// checkPatchedFields rejects patches that change an immutable field of the
+// current document or write a field only the classifier sets.
+func checkPatchedFields(current []byte, patch map[string]any) *apierror.Error {
+	var document map[string]any
+	if err := json.Unmarshal(current, &document); err != nil {
+		return apierror.Internal("Failed to read the document", err)
+	}
+
+	for _, field := range immutableFields {
+		value, patched := patch[field]
+		if !patched {
+			continue
+		}
+		same, err := sameJSONValue(value, document[field])
+		if err != nil {
+			return apierror.Internal("Failed to read the document", err)
+		}
+		if !same {
+			return apierror.New(http.StatusBadRequest, apierror.CodeImmutableField, field+" cannot be changed")
+		}
+	}
+	for _, field := range classifiedFields {
+		if _, patched := patch[field]; patched {
+			return apierror.New(http.StatusBadRequest, apierror.CodeImmutableField,
+				field+" is set by the admin review endpoint")
+		}
+	}
+
+	return nil
+}
+
+// sameJSONValue reports whether a and b write the same JSON value, whatever
+// the Go types holding them.
+func sameJSONValue(a, b any) (bool, error) {
+	normalise := func(value any) (any, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var normalised any
+		err = json.Unmarshal(data, &normalised)
+		return normalised, err
+	}
+
+	normalisedA, err := normalise(a)
+	if err != nil {
+		return false, err
+	}
+	normalisedB, err := normalise(b)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(normalisedA, normalisedB), nil
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSameJSONValue(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{"int and float", 1, 1.0, true},
+		{"string and number", "1", 1.0, false},
+		{"ordered and plain document", bson.D{{Key: "a", Value: 1}}, map[string]any{"a": 1.0}, true},
+		{"object id and its hex", bson.ObjectID{1}, bson.ObjectID{1}.Hex(), true},
+		{"missing and null", nil, nil, true},
+		{"null and empty string", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			same, err := sameJSONValue(tt.a, tt.b)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, same)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"server/models"
+	"server/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseTimeQuery reads an ISO-8601 date or time from the query string, the
+// zero time when the parameter is absent. A date is taken at its start.
+func parseTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	parsed, _, err := parseDayOrTimeQuery(c, key)
+	return parsed, err
+}
+
+// parseEndQuery reads the exclusive upper bound of a time range like
+// parseTimeQuery, except that a date is taken at the start of the next day
+// so that the range covers all of it.
+func parseEndQuery(c *gin.Context, key string) (time.Time, error) {
+	parsed, day, err := parseDayOrTimeQuery(c, key)
+	if day {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return parsed, err
+}
+
+// parseDateRange reads the from and to query parameters bounding a date,
+// both inclusive. A day as to covers all of it.
+func parseDateRange(c *gin.Context) (repository.DateRange, error) {
+	var between repository.DateRange
+
+	var err error
+	if between.From, err = parseTimeQuery(c, "from"); err != nil {
+		return between, err
+	}
+	to, day, err := parseDayOrTimeQuery(c, "to")
+	if err != nil {
+		return between, err
+	}
+	if day {
+		to = to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	between.To = to
+
+	if !between.From.IsZero() && !between.To.IsZero() && between.To.Before(between.From) {
+		return between, errors.New("from must not be after to")
+	}
+	return between, nil
+}
+
+// parseDayOrTimeQuery reads a date or time with models.ParseDate and reports
+// whether it was a day alone.
+func parseDayOrTimeQuery(c *gin.Context, key string) (time.Time, bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+
+	parsed, err := models.ParseDate(value)
+	if err != nil {
+		return time.Time{}, false, errors.New(key + " " + err.Error())
+	}
+	_, err = time.Parse(time.DateOnly, value)
+	return parsed, err == nil, nil
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDateRange(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, _ := time.Parse(time.RFC3339Nano, value)
+		return parsed
+	}
+
+	tests := []struct {
+		query   string
+		want    repository.DateRange
+		wantErr bool
+	}{
+		{"", repository.DateRange{}, false},
+		{
+			"from=2024-01-01&to=2024-01-31",
+			repository.DateRange{From: at("2024-01-01T00:00:00Z"), To: at("2024-01-31T23:59:59.999999999Z")},
+			false,
+		},
+		{
+			"from=2024-01-01T08:00:00Z&to=2024-01-31T20:00:00Z",
+			repository.DateRange{From: at("2024-01-01T08:00:00Z"), To: at("2024-01-31T20:00:00Z")},
+			false,
+		},
+		{
+			"from=2024-01-31&to=2024-01-31",
+			repository.DateRange{From: at("2024-01-31T00:00:00Z"), To: at("2024-01-31T23:59:59.999999999Z")},
+			false,
+		},
+		{"from=2024-02-01&to=2024-01-31", repository.DateRange{}, true},
+		{"to=31/01/2024", repository.DateRange{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?"+tt.query, nil)
+
+			between, err := parseDateRange(c)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.want.From.Equal(between.From), "from %v", between.From)
+			assert.True(t, tt.want.To.Equal(between.To), "to %v", between.To)
+		})
+	}
+}
+
+func TestParseEndQuery(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"2024-01-31", "2024-02-01T00:00:00Z"},
+		{"2024-01-31T20:00:00Z", "2024-01-31T20:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/?to="+tt.value, nil)
+
+			end, err := parseEndQuery(c, "to")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, end.Format(time.RFC3339))
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"server/apierror"
+	"server/models"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// likedRatingScore is the lowest rating that counts as the user liking a title.
+const likedRatingScore = 4
+
+func (app *App) RateTitle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
+			return
+		}
+
+		var rating models.Rating
+		if err := c.ShouldBindJSON(&rating); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if err := validate.Struct(rating); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		exists, err := app.titleExists(ctx, rating.ContentType, imdbID)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to check title", err))
+			return
+		}
+		if !exists {
+			utils.RespondError(c, apierror.NotFound("Title not found"))
+			return
+		}
+
+		rating.UserID = userId
+		rating.ImdbID = imdbID
+		if err := app.Repos.Ratings.Upsert(ctx, rating); err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to save rating", err))
+			return
+		}
+
+		app.recordTitleEvent(c, imdbID, rating.ContentType, models.TitleEventRating)
+
+		c.JSON(http.StatusOK, gin.H{"Message": "Rating saved successfully"})
+	}
+}
+
+func (app *App) GetUserRatings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		ratings, err := app.Repos.Ratings.FindByUser(ctx, userId)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch ratings", err))
+			return
+		}
+		ratings, err = liveEntries(ctx, app, ratings, func(rating *models.Rating) (string, string) {
+			return rating.ContentType, rating.ImdbID
+		})
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch ratings", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ratings)
+	}
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+// titleExists reports whether the catalog of the given content type holds the
+// title, false when the content type is unknown.
+func (app *App) titleExists(ctx context.Context, contentType, imdbID string) (bool, error) {
+	catalog := app.Repos.Catalog(contentType)
+	if catalog == nil {
+		return false, nil
+	}
+
+	return catalog.Exists(ctx, imdbID)
+}
+
+// liveEntries keeps the entries whose title is in the catalog, leaving out
+// those of titles in the trash or gone. title gives the content type and
+// IMDB ID an entry refers to.
+func liveEntries[E any](ctx context.Context, app *App, entries []E,
+	title func(*E) (string, string)) ([]E, error) {
+
+	imdbIDs := map[string][]string{}
+	for i := range entries {
+		contentType, imdbID := title(&entries[i])
+		imdbIDs[contentType] = append(imdbIDs[contentType], imdbID)
+	}
+
+	live := map[string]bool{}
+	for contentType, ids := range imdbIDs {
+		catalog := app.Repos.Catalog(contentType)
+		if catalog == nil {
+			continue
+		}
+		summaries, err := catalog.Summaries(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, summary := range summaries {
+			live[contentType+"/"+summary.ImdbID] = true
+		}
+	}
+
+	kept := make([]E, 0, len(entries))
+	for i := range entries {
+		contentType, imdbID := title(&entries[i])
+		if live[contentType+"/"+imdbID] {
+			kept = append(kept, entries[i])
+		}
+	}
+	return kept, nil
+}
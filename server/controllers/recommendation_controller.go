@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"server/apierror"
+	"server/config"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feedCursor is the position reached in each collection by the previous
+// pages of the mixed feed, along with the IMDB IDs they served so that a
+// title in both collections is not served twice. It travels to the client as
+// an opaque base64 string.
+type feedCursor struct {
+	MovieOffset  int64    `json:"m"`
+	TVShowOffset int64    `json:"t"`
+	Seen         []string `json:"s,omitempty"`
+}
+
+// recommendationHandler serves the top scored titles of a content type for
+// the user in the context. Movies and TV shows share it, only the repository
+// and the limit differ.
+func recommendationHandler[T any](app *App, titles repository.TitleRepository[T],
+	limit int64, kind string) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		favouriteGenres, err := GetUsersFavouriteGenres(ctx, app.Repos.Users, userId)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching favourite genres", err))
+			return
+		}
+
+		query, err := app.recommendationQueryFor(ctx, titles.ContentType(), favouriteGenres,
+			app.recommendationWeights(), 0, limit)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching trending "+kind, err))
+			return
+		}
+
+		items, err := titles.Recommend(ctx, query)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching recommended "+kind, err))
+			return
+		}
+
+		imdbIDs := make([]string, 0, len(items))
+		for _, item := range items {
+			imdbIDs = append(imdbIDs, item.ImdbID)
+		}
+		recommended, err := titles.FindByImdbIDs(ctx, imdbIDs)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching recommended "+kind, err))
+			return
+		}
+
+		c.JSON(http.StatusOK, recommended)
+	}
+}
+
+// GetRecommendations returns a single feed alternating recommended movies and
+// TV shows. The number of each kind per page defaults to the configured movie
+// and TV show limits and can be lowered or raised per request with
+// the movie_limit and tv_show_limit query parameters.
+func (app *App) GetRecommendations() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		movieLimit, err := parseLimitQuery(c, "movie_limit", app.Config.Recommendations.MovieLimit)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+		tvShowLimit, err := parseLimitQuery(c, "tv_show_limit", app.Config.Recommendations.TVShowLimit)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+
+		cursor, err := decodeFeedCursor(c.Query("cursor"))
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid cursor").Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		favouriteGenres, err := GetUsersFavouriteGenres(ctx, app.Repos.Users, userId)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching favourite genres", err))
+			return
+		}
+
+		weights := app.recommendationWeights()
+
+		// One title past each limit tells whether there is a next page
+		movieQuery, err := app.recommendationQueryFor(ctx, models.ContentTypeMovie, favouriteGenres,
+			weights, cursor.MovieOffset, movieLimit+1)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching trending movies", err))
+			return
+		}
+		movies, err := app.Repos.Movies.Recommend(ctx, movieQuery)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching recommended movies", err))
+			return
+		}
+
+		tvShowQuery, err := app.recommendationQueryFor(ctx, models.ContentTypeTVShow, favouriteGenres,
+			weights, cursor.TVShowOffset, tvShowLimit+1)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching trending TV shows", err))
+			return
+		}
+		tvShows, err := app.Repos.TVShows.Recommend(ctx, tvShowQuery)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Error fetching recommended TV shows", err))
+			return
+		}
+
+		moreMovies := int64(len(movies)) > movieLimit
+		if moreMovies {
+			movies = movies[:movieLimit]
+		}
+		moreTVShows := int64(len(tvShows)) > tvShowLimit
+		if moreTVShows {
+			tvShows = tvShows[:tvShowLimit]
+		}
+
+		seen := make(map[string]bool, len(cursor.Seen))
+		for _, imdbID := range cursor.Seen {
+			seen[imdbID] = true
+		}
+		feed := models.RecommendationFeed{Items: interleaveRecommendations(movies, tvShows, seen)}
+		if moreMovies || moreTVShows {
+			next := feedCursor{
+				MovieOffset:  cursor.MovieOffset + int64(len(movies)),
+				TVShowOffset: cursor.TVShowOffset + int64(len(tvShows)),
+				Seen:         cursor.Seen,
+			}
+			for _, item := range feed.Items {
+				next.Seen = append(next.Seen, item.ImdbID)
+			}
+			feed.NextCursor = encodeFeedCursor(next)
+		}
+
+		c.JSON(http.StatusOK, feed)
+	}
+}
+
+// recommendationQueryFor personalises the query when the user has favourite
+// genres, and falls back to top-ranked and trending titles otherwise.
+func (app *App) recommendationQueryFor(ctx context.Context, contentType string, favouriteGenres []string,
+	weights repository.RecommendationWeights, skip, limit int64) (repository.RecommendationQuery, error) {
+
+	query := repository.RecommendationQuery{
+		FavouriteGenres: favouriteGenres,
+		Weights:         weights,
+		Skip:            skip,
+		Limit:           limit,
+	}
+	if len(favouriteGenres) > 0 {
+		return query, nil
+	}
+
+	trending, err := getTrendingScores(ctx, app.Repos.Charts, contentType)
+	if err != nil {
+		return query, err
+	}
+	query.Trending = trending
+
+	return query, nil
+}
+
+// getTrendingScores reads the weekly trending chart of a content type,
+// normalised so the top title scores 1. Titles are not trending until the
+// chart has been built.
+func getTrendingScores(ctx context.Context, charts repository.ChartRepository,
+	contentType string) (map[string]float64, error) {
+
+	chart, err := charts.FindByID(ctx, models.ChartID(models.ChartWindowWeekly, contentType, models.ChartAllGenres))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return map[string]float64{}, nil
+		}
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(chart.Entries))
+	if len(chart.Entries) == 0 || chart.Entries[0].Score <= 0 {
+		return scores, nil
+	}
+	top := chart.Entries[0].Score
+	for _, entry := range chart.Entries {
+		scores[entry.ImdbID] = entry.Score / top
+	}
+
+	return scores, nil
+}
+
+// interleaveRecommendations alternates movies and TV shows, keeping each list's
+// order, and drops any IMDB ID in seen or already present in the feed. seen
+// gets the IMDB IDs added.
+func interleaveRecommendations(movies, tvShows []models.RecommendationItem,
+	seen map[string]bool) []models.RecommendationItem {
+
+	items := make([]models.RecommendationItem, 0, len(movies)+len(tvShows))
+
+	add := func(item models.RecommendationItem, contentType string) {
+		if seen[item.ImdbID] {
+			return
+		}
+		seen[item.ImdbID] = true
+		item.ContentType = contentType
+		items = append(items, item)
+	}
+
+	for i := 0; i < len(movies) || i < len(tvShows); i++ {
+		if i < len(movies) {
+			add(movies[i], models.ContentTypeMovie)
+		}
+		if i < len(tvShows) {
+			add(tvShows[i], models.ContentTypeTVShow)
+		}
+	}
+
+	return items
+}
+
+func encodeFeedCursor(cursor feedCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeFeedCursor(value string) (feedCursor, error) {
+	var cursor feedCursor
+	if value == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	if cursor.MovieOffset < 0 || cursor.TVShowOffset < 0 {
+		return cursor, errors.New("negative cursor offset")
+	}
+
+	return cursor, nil
+}
+
+// parseLimitQuery reads a positive limit from the query string, falling back
+// to the configured one when the parameter is absent.
+func parseLimitQuery(c *gin.Context, key string, fallback int64) (int64, error) {
+	return parseBoundedLimitQuery(c, key, fallback, config.MaxRecommendationLimit)
+}
+
+// parseBoundedLimitQuery is parseLimitQuery for pages capped at maxLimit
+// rather than config.MaxRecommendationLimit.
+func parseBoundedLimitQuery(c *gin.Context, key string, fallback, maxLimit int64) (int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 1 || limit > maxLimit {
+		return 0, errors.New(key + " must be a number between 1 and " + strconv.FormatInt(maxLimit, 10))
+	}
+
+	return limit, nil
+}
+
+// parseSkipQuery reads the skip query parameter, 0 when absent.
+func parseSkipQuery(c *gin.Context) (int64, error) {
+	value := c.Query("skip")
+	if value == "" {
+		return 0, nil
+	}
+
+	skip, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || skip < 0 {
+		return 0, errors.New("skip must be a non negative number")
+	}
+
+	return skip, nil
+}
+
+func (app *App) recommendationWeights() repository.RecommendationWeights {
+	return repository.RecommendationWeights(app.Config.Recommendations.Weights)
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/models"
+	"server/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testGenreIDs = map[string]int{"Action": 1, "Comedy": 2, "Drama": 3}
+
+// rankedMovie is a movie with the given ranking value and genres, the only
+// fields the recommendation scores read besides its id.
+func rankedMovie(imdbID string, rankingValue int, genres ...string) models.Movie {
+	movie := testMovie(imdbID, "Movie "+imdbID)
+	movie.Ranking = models.Ranking{RankingValue: rankingValue}
+	movie.Genre = []models.Genre{}
+	for _, name := range genres {
+		movie.Genre = append(movie.Genre, models.Genre{GenreID: testGenreIDs[name], GenreName: name})
+	}
+	return movie
+}
+
+func imdbIDsOf(items []models.RecommendationItem) []string {
+	imdbIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		imdbIDs = append(imdbIDs, item.ImdbID)
+	}
+	return imdbIDs
+}
+
+func TestRecommend_ScoreOrdering(t *testing.T) {
+	movies := repository.NewMemoryMovieRepository()
+	for _, movie := range []models.Movie{
+		rankedMovie("tt0000001", 5, "Action"),
+		rankedMovie("tt0000002", 1, "Action"),
+		rankedMovie("tt0000003", 999, "Action", "Comedy"),
+		rankedMovie("tt0000004", 2, "Drama"),
+		rankedMovie("tt0000005", 999, "Comedy"),
+	} {
+		_, err := movies.Insert(t.Context(), movie)
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		name  string
+		query repository.RecommendationQuery
+		want  []string
+	}{
+		{
+			name: "matched genres then ranking",
+			query: repository.RecommendationQuery{
+				FavouriteGenres: []string{"Action", "Comedy"},
+				Weights:         repository.RecommendationWeights{Genre: 1, Ranking: 0.5},
+			},
+			want: []string{"tt0000003", "tt0000002", "tt0000001", "tt0000005"},
+		},
+		{
+			name: "ties broken by ranking value",
+			query: repository.RecommendationQuery{
+				FavouriteGenres: []string{"Action"},
+				Weights:         repository.RecommendationWeights{Genre: 1},
+			},
+			want: []string{"tt0000002", "tt0000001", "tt0000003"},
+		},
+		{
+			name: "without favourite genres by ranking and trending",
+			query: repository.RecommendationQuery{
+				Trending: map[string]float64{"tt0000005": 1, "tt0000004": 0.5},
+				Weights:  repository.RecommendationWeights{Ranking: 1, Trending: 1},
+			},
+			want: []string{"tt0000002", "tt0000004", "tt0000005", "tt0000001", "tt0000003"},
+		},
+		{
+			name: "skip and limit page the order",
+			query: repository.RecommendationQuery{
+				FavouriteGenres: []string{"Action", "Comedy"},
+				Weights:         repository.RecommendationWeights{Genre: 1, Ranking: 0.5},
+				Skip:            1,
+				Limit:           2,
+			},
+			want: []string{"tt0000002", "tt0000001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := movies.Recommend(t.Context(), tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, imdbIDsOf(items))
+		})
+	}
+}
+
+func TestGetRecommendedMovies_FavouriteGenres(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/recommended_movies", asUser, app.GetRecommendedMovies())
+
+	_, err := app.Repos.Users.Insert(t.Context(), models.User{
+		UserID:          "user-1",
+		Email:           "user@example.com",
+		FavouriteGenres: []models.Genre{{GenreID: 2, GenreName: "Comedy"}},
+	})
+	assert.NoError(t, err)
+	for _, movie := range []models.Movie{
+		rankedMovie("tt0000001", 1, "Action"),
+		rankedMovie("tt0000002", 999, "Comedy"),
+		rankedMovie("tt0000003", 3, "Comedy"),
+	} {
+		_, err := app.Repos.Movies.Insert(t.Context(), movie)
+		assert.NoError(t, err)
+	}
+
+	req, _ := http.NewRequest("GET", "/recommended_movies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var movies []models.Movie
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &movies))
+	if assert.Len(t, movies, 2) {
+		assert.Equal(t, "tt0000003", movies[0].ImdbID)
+		assert.Equal(t, "tt0000002", movies[1].ImdbID)
+	}
+}
+
+func TestInterleaveRecommendations(t *testing.T) {
+	items := func(imdbIDs ...string) []models.RecommendationItem {
+		list := []models.RecommendationItem{}
+		for _, imdbID := range imdbIDs {
+			list = append(list, models.RecommendationItem{ImdbID: imdbID})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name    string
+		movies  []models.RecommendationItem
+		tvShows []models.RecommendationItem
+		seen    map[string]bool
+		want    []string
+	}{
+		{"alternates", items("m1", "m2"), items("t1", "t2"), nil, []string{"m1", "t1", "m2", "t2"}},
+		{"appends the longer list", items("m1", "m2", "m3"), items("t1"), nil, []string{"m1", "t1", "m2", "m3"}},
+		{"drops repeated ids", items("m1", "x1"), items("x1", "t2"), nil, []string{"m1", "x1", "t2"}},
+		{"drops ids of earlier pages", items("m1", "x1"), items("t1"), map[string]bool{"x1": true}, []string{"m1", "t1"}},
+		{"empty", items(), items(), nil, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := map[string]bool{}
+			for imdbID := range tt.seen {
+				seen[imdbID] = true
+			}
+			items := interleaveRecommendations(tt.movies, tt.tvShows, seen)
+			assert.Equal(t, tt.want, imdbIDsOf(items))
+			for _, item := range items {
+				assert.True(t, seen[item.ImdbID])
+			}
+		})
+	}
+}
+
+func TestGetRecommendations_CursorPages(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/recommendations", asUser, app.GetRecommendations())
+	// The TV shows tie on ranking, keep a second boundary from reordering them
+	app.Config.Recommendations.Weights.Recency = 0
+
+	for i := 1; i <= 4; i++ {
+		_, err := app.Repos.Movies.Insert(t.Context(), rankedMovie(fmt.Sprintf("tt000000%d", i), i, "Action"))
+		assert.NoError(t, err)
+	}
+	for i := 1; i <= 2; i++ {
+		_, err := app.Repos.TVShows.Insert(t.Context(), testTVShow(fmt.Sprintf("tt100000%d", i), fmt.Sprintf("Show %d", i), 1))
+		assert.NoError(t, err)
+	}
+	// A TV show sharing the IMDB ID of the first movie comes last, on the
+	// second page, and is not served again
+	shared := testTVShow("tt0000001", "Shared", 1)
+	shared.Ranking = models.Ranking{RankingValue: 999, RankingName: "Not Ranked"}
+	_, err := app.Repos.TVShows.Insert(t.Context(), shared)
+	assert.NoError(t, err)
+
+	seen := map[string]bool{}
+	var pages [][]models.RecommendationItem
+	cursor := ""
+	for range 10 {
+		req, _ := http.NewRequest("GET", "/recommendations?movie_limit=2&tv_show_limit=2&cursor="+cursor, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var feed models.RecommendationFeed
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+		pages = append(pages, feed.Items)
+		for _, item := range feed.Items {
+			assert.False(t, seen[item.ImdbID], "%s repeated", item.ImdbID)
+			seen[item.ImdbID] = true
+		}
+
+		if feed.NextCursor == "" {
+			break
+		}
+		cursor = feed.NextCursor
+	}
+
+	// The pages end with the last titles rather than with an empty page
+	assert.Len(t, seen, 6)
+	assert.Len(t, pages, 2)
+	assert.Equal(t, []string{"tt0000001", "tt1000001", "tt0000002", "tt1000002"}, imdbIDsOf(pages[0]))
+	assert.Equal(t, models.ContentTypeMovie, pages[0][0].ContentType)
+	assert.Equal(t, models.ContentTypeTVShow, pages[0][1].ContentType)
+	assert.Equal(t, []string{"tt0000003", "tt0000004"}, imdbIDsOf(pages[1]))
+
+	req, _ := http.NewRequest("GET", "/recommendations?cursor=not-a-cursor", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// untrackedFields change with every write, the revision records them apart.
+var untrackedFields = []string{"_id", "version"}
+
+func (app *App) GetMovieRevisions() gin.HandlerFunc {
+	return revisionsHandler(app, models.ContentTypeMovie)
+}
+
+func (app *App) GetMovieRevision() gin.HandlerFunc {
+	return revisionHandler(app, models.ContentTypeMovie)
+}
+
+func (app *App) RollbackMovie() gin.HandlerFunc {
+	return rollbackHandler(app, app.Repos.Movies, "Movie", movieVersion)
+}
+
+func (app *App) GetTVShowRevisions() gin.HandlerFunc {
+	return revisionsHandler(app, models.ContentTypeTVShow)
+}
+
+func (app *App) GetTVShowRevision() gin.HandlerFunc {
+	return revisionHandler(app, models.ContentTypeTVShow)
+}
+
+func (app *App) RollbackTVShow() gin.HandlerFunc {
+	return rollbackHandler(app, app.Repos.TVShows, "TV show", tvShowVersion)
+}
+
+// revisionsHandler lists the revisions of a title, newest first. A deleted
+// title keeps its revisions, so an unknown title is an empty list.
+func revisionsHandler(app *App, contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		revisions, err := app.Repos.Revisions.FindByTitle(ctx, contentType, imdbID)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch revisions", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, revisions)
+	}
+}
+
+// revisionHandler serves a revision with its changes and document.
+func revisionHandler(app *App, contentType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		revision, ok := findRevision(ctx, c, app.Repos.Revisions, contentType)
+		if !ok {
+			return
+		}
+
+		c.JSON(http.StatusOK, revision)
+	}
+}
+
+// rollbackHandler restores the document of a revision, recreating the title
+// when it was deleted since. The restore is recorded as a revision itself.
+func rollbackHandler[T any](app *App, titles repository.TitleRepository[T], kind string,
+	version func(*T) *int64) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		revision, ok := findRevision(ctx, c, app.Repos.Revisions, titles.ContentType())
+		if !ok {
+			return
+		}
+		if revision.Document == nil {
+			utils.RespondError(c, apierror.BadRequest("A delete revision has no document to restore"))
+			return
+		}
+
+		// The title keeps its _id, or gets a new one when recreated
+		document := maps.Clone(revision.Document)
+		delete(document, "_id")
+
+		restored, err := fromDocument[T](document)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to read the revision", err))
+			return
+		}
+		if err := validate.Struct(restored); err != nil {
+			utils.RespondError(c, apierror.Validation("The revision is no longer valid", err))
+			return
+		}
+
+		var before *T
+		current, err := titles.FindByImdbID(ctx, revision.ImdbID)
+		switch {
+		case err == nil:
+			ifMatch := c.GetHeader("If-Match")
+			if ifMatch != "" && !utils.ETagMatches(ifMatch, utils.ETag(*version(&current)), false) {
+				utils.RespondError(c, versionConflict(kind))
+				return
+			}
+			before = &current
+			_, err = titles.Replace(ctx, revision.ImdbID, restored, *version(&current))
+		case errors.Is(err, repository.ErrNotFound):
+			_, err = titles.Insert(ctx, restored)
+		}
+		if err != nil {
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict(kind))
+				return
+			}
+			if errors.Is(err, repository.ErrInTrash) {
+				utils.RespondError(c, apierror.Conflict(kind+" is in the trash, restore it first"))
+				return
+			}
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict(kind+" was recreated in the meantime"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to restore "+kind, err))
+			return
+		}
+
+		recordRevision(app, c, titles, revision.ImdbID, models.RevisionRollback, before)
+
+		title, err := titles.FindByImdbID(ctx, revision.ImdbID)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch "+kind, err))
+			return
+		}
+		c.Header("ETag", utils.ETag(*version(&title)))
+		c.JSON(http.StatusOK, title)
+	}
+}
+
+// recordRevision stores the revision of a write to a title, made by the
+// authenticated user. before is the title as the write found it, nil for a
+// creation; the title as the write left it is read back. Failures are only
+// logged, the write already happened.
+func recordRevision[T any](app *App, c *gin.Context, titles repository.TitleRepository[T],
+	imdbID, action string, before *T) {
+
+	ctx, cancel := app.dbContext(c)
+	defer cancel()
+
+	userId, _ := utils.GetUserIdFromContext(c)
+	if err := saveRevision(ctx, app, titles, imdbID, action, userId, before); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Error recording revision",
+			"action", action, "imdb_id", imdbID, "error", err)
+	}
+}
+
+// saveRevision stores the revision of a write to a title made by userId,
+// as recordRevision describes.
+func saveRevision[T any](ctx context.Context, app *App, titles repository.TitleRepository[T],
+	imdbID, action, userId string, before *T) error {
+
+	revision := models.Revision{
+		ImdbID:      imdbID,
+		ContentType: titles.ContentType(),
+		Action:      action,
+		UserID:      userId,
+		CreatedAt:   time.Now(),
+	}
+
+	previous, err := toDocument(before)
+	if err != nil {
+		return err
+	}
+
+	var after *T
+	title, err := titles.FindByImdbID(ctx, imdbID)
+	if err == nil {
+		after = &title
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	revision.Document, err = toDocument(after)
+	if err != nil {
+		return err
+	}
+
+	// The revision of a delete keeps the version that was deleted
+	if revision.Document != nil {
+		revision.Version = documentVersion(revision.Document)
+	} else if previous != nil {
+		revision.Version = documentVersion(previous)
+	}
+	revision.Changes = diffDocuments(previous, revision.Document)
+
+	return app.Repos.Revisions.Insert(ctx, revision)
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+// findRevision loads the revision named by the path, which must belong to
+// the title of the path. Otherwise it responds with the error and returns
+// false.
+func findRevision(ctx context.Context, c *gin.Context, revisions repository.RevisionRepository,
+	contentType string) (models.Revision, bool) {
+
+	id, err := bson.ObjectIDFromHex(c.Param("revision_id"))
+	if err != nil {
+		utils.RespondError(c, apierror.BadRequest("Invalid revision ID"))
+		return models.Revision{}, false
+	}
+
+	revision, err := revisions.FindByID(ctx, id)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(c, apierror.Internal("Failed to fetch revision", err))
+		return revision, false
+	}
+	if err != nil || revision.ContentType != contentType || revision.ImdbID != c.Param("imdb_id") {
+		utils.RespondError(c, apierror.NotFound("Revision not found"))
+		return revision, false
+	}
+
+	return revision, true
+}
+
+// toDocument returns the JSON form of the title, nil for a nil title.
+func toDocument[T any](title *T) (map[string]any, error) {
+	if title == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(title)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]any
+	err = json.Unmarshal(data, &document)
+	return document, err
+}
+
+func fromDocument[T any](document map[string]any) (T, error) {
+	var title T
+	data, err := json.Marshal(document)
+	if err != nil {
+		return title, err
+	}
+	err = json.Unmarshal(data, &title)
+	return title, err
+}
+
+func documentVersion(document map[string]any) int64 {
+	version, _ := document["version"].(float64)
+	return int64(version)
+}
+
+// diffDocuments lists the fields that differ between two JSON documents,
+// either of which may be nil, sorted by path. Objects are compared field by
+// field and any other value, arrays included, as a whole.
+func diffDocuments(before, after map[string]any) []models.FieldChange {
+	changes := []models.FieldChange{}
+	diffFields("", before, after, &changes)
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func diffFields(prefix string, before, after map[string]any, changes *[]models.FieldChange) {
+	keys := map[string]bool{}
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		if prefix == "" && slices.Contains(untrackedFields, key) {
+			continue
+		}
+
+		path := prefix + key
+		oldValue, hadValue := before[key]
+		newValue, hasValue := after[key]
+
+		oldObject, oldIsObject := oldValue.(map[string]any)
+		newObject, newIsObject := newValue.(map[string]any)
+		if oldIsObject && newIsObject {
+			diffFields(path+".", oldObject, newObject, changes)
+			continue
+		}
+
+		if hadValue == hasValue && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		*changes = append(*changes, models.FieldChange{Field: path, Old: oldValue, New: newValue})
+	}
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffDocuments(t *testing.T) {
+	before := map[string]any{
+		"_id":     "1",
+		"version": 1.0,
+		"title":   "Test Movie",
+		"ranking": map[string]any{"ranking_value": 2.0, "ranking_name": "Good"},
+		"genre":   []any{"Action"},
+	}
+	after := map[string]any{
+		"_id":          "1",
+		"version":      2.0,
+		"title":        "Test Movie",
+		"ranking":      map[string]any{"ranking_value": 1.0, "ranking_name": "Good"},
+		"genre":        []any{"Action", "Drama"},
+		"admin_review": "A masterpiece",
+	}
+
+	assert.Equal(t, []models.FieldChange{
+		{Field: "admin_review", New: "A masterpiece"},
+		{Field: "genre", Old: []any{"Action"}, New: []any{"Action", "Drama"}},
+		{Field: "ranking.ranking_value", Old: 2.0, New: 1.0},
+	}, diffDocuments(before, after))
+
+	assert.Len(t, diffDocuments(nil, after), 4)
+	assert.Empty(t, diffDocuments(after, after))
+}
+
+func TestMovieRevisionsAndRollback(t *testing.T) {
+	router, app := setupTestRouter()
+	router.POST("/add_movie", asAdmin, app.AddMovie())
+	router.PATCH("/movie/:imdb_id", asAdmin, app.PatchMovie())
+	router.DELETE("/delete_movie/:imdb_id", asAdmin, app.DeleteMovie())
+	router.GET("/movie/:imdb_id/revisions", app.GetMovieRevisions())
+	router.GET("/movie/:imdb_id/revisions/:revision_id", app.GetMovieRevision())
+	router.POST("/movie/:imdb_id/revisions/:revision_id/rollback", asAdmin, app.RollbackMovie())
+	router.POST("/movie/:imdb_id/restore", asAdmin, app.RestoreMovie())
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	revisions := func() []models.Revision {
+		w := request("GET", "/movie/tt0000001/revisions", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var revisions []models.Revision
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &revisions))
+		return revisions
+	}
+
+	jsonData, _ := json.Marshal(testMovie("tt0000001", "Test Movie"))
+	assert.Equal(t, http.StatusCreated, request("POST", "/add_movie", string(jsonData)).Code)
+	assert.Equal(t, http.StatusOK, request("PATCH", "/movie/tt0000001", `{"title": "Renamed Movie"}`).Code)
+	assert.Equal(t, http.StatusOK, request("DELETE", "/delete_movie/tt0000001", "").Code)
+
+	history := revisions()
+	assert.Len(t, history, 3)
+	assert.Equal(t, models.RevisionDelete, history[0].Action)
+	assert.Equal(t, models.RevisionUpdate, history[1].Action)
+	assert.Equal(t, models.RevisionCreate, history[2].Action)
+	assert.Equal(t, "admin-1", history[1].UserID)
+	assert.Equal(t, int64(2), history[1].Version)
+
+	w := request("GET", "/movie/tt0000001/revisions/"+history[1].ID.Hex(), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var update models.Revision
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &update))
+	assert.Equal(t, []models.FieldChange{{Field: "title", Old: "Test Movie", New: "Renamed Movie"}}, update.Changes)
+
+	// A deleted movie is in the trash, it must be restored before a rollback
+	w = request("POST", "/movie/tt0000001/revisions/"+history[2].ID.Hex()+"/rollback", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, http.StatusOK, request("POST", "/movie/tt0000001/restore", "").Code)
+	assert.Equal(t, models.RevisionRestore, revisions()[0].Action)
+
+	// Bring the movie back as it was created
+	w = request("POST", "/movie/tt0000001/revisions/"+history[2].ID.Hex()+"/rollback", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	movie, err := app.Repos.Movies.FindByImdbID(t.Context(), "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Movie", movie.Title)
+	assert.Equal(t, models.RevisionRollback, revisions()[0].Action)
+
+	w = request("POST", "/movie/tt0000001/revisions/"+history[0].ID.Hex()+"/rollback", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request("GET", "/movie/tt0000002/revisions/"+history[1].ID.Hex(), "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
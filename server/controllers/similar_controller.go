@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (app *App) GetSimilarMovies() gin.HandlerFunc {
+	return app.similarTitlesHandler(models.ContentTypeMovie)
+}
+
+func (app *App) GetSimilarTVShows() gin.HandlerFunc {
+	return app.similarTitlesHandler(models.ContentTypeTVShow)
+}
+
+// similarTitlesHandler lists the titles most similar to the one in the path.
+// Results are of the same content type unless the content_type query asks for
+// "movie", "tv_show" or "all". The limit query defaults to the configured
+// similar titles limit.
+func (app *App) similarTitlesHandler(sourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
+			return
+		}
+
+		limit, err := parseLimitQuery(c, "limit", app.Config.Recommendations.SimilarTitlesLimit)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+
+		var targetTypes []string
+		switch contentType := c.DefaultQuery("content_type", sourceType); contentType {
+		case models.ContentTypeMovie, models.ContentTypeTVShow:
+			targetTypes = []string{contentType}
+		case "all":
+			targetTypes = []string{models.ContentTypeMovie, models.ContentTypeTVShow}
+		default:
+			utils.RespondError(c, apierror.BadRequest("content_type must be movie, tv_show or all"))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		sources, err := app.Repos.Catalog(sourceType).Summaries(ctx, []string{imdbID})
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch title", err))
+			return
+		}
+		if len(sources) == 0 {
+			utils.RespondError(c, apierror.NotFound("Title not found"))
+			return
+		}
+
+		coActivity, err := app.getCoActivityScores(ctx, imdbID)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch co-activity", err))
+			return
+		}
+
+		query := repository.SimilarityQuery{Source: sources[0], CoActivity: coActivity, Limit: limit}
+		similar := []models.RecommendationItem{}
+		for _, contentType := range targetTypes {
+			items, err := app.Repos.Catalog(contentType).Similar(ctx, query)
+			if err != nil {
+				utils.RespondError(c, apierror.Internal("Failed to fetch similar titles", err))
+				return
+			}
+			similar = append(similar, items...)
+		}
+
+		sort.SliceStable(similar, func(i, j int) bool {
+			return similar[i].Score > similar[j].Score
+		})
+		if int64(len(similar)) > limit {
+			similar = similar[:limit]
+		}
+
+		c.JSON(http.StatusOK, similar)
+	}
+}
+
+// getCoActivityScores finds the users who liked or saved the title and
+// returns, for every other title, the fraction of them who also liked or
+// saved it.
+func (app *App) getCoActivityScores(ctx context.Context, imdbID string) (map[string]float64, error) {
+
+	raters, err := app.Repos.Ratings.FindLiked(ctx, imdbID, nil, likedRatingScore)
+	if err != nil {
+		return nil, err
+	}
+	watchers, err := app.Repos.Watchlist.FindSaved(ctx, imdbID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	audience := map[string]bool{}
+	for _, rating := range raters {
+		audience[rating.UserID] = true
+	}
+	for _, item := range watchers {
+		audience[item.UserID] = true
+	}
+	if len(audience) == 0 {
+		return map[string]float64{}, nil
+	}
+
+	userIds := make([]string, 0, len(audience))
+	for userId := range audience {
+		userIds = append(userIds, userId)
+	}
+
+	liked, err := app.Repos.Ratings.FindLiked(ctx, "", userIds, likedRatingScore)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := app.Repos.Watchlist.FindSaved(ctx, "", userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	usersByTitle := map[string]map[string]bool{}
+	addActivity := func(titleID, userId string) {
+		if titleID == imdbID {
+			return
+		}
+		if usersByTitle[titleID] == nil {
+			usersByTitle[titleID] = map[string]bool{}
+		}
+		usersByTitle[titleID][userId] = true
+	}
+	for _, rating := range liked {
+		addActivity(rating.ImdbID, rating.UserID)
+	}
+	for _, item := range saved {
+		addActivity(item.ImdbID, item.UserID)
+	}
+
+	scores := make(map[string]float64, len(usersByTitle))
+	for titleID, users := range usersByTitle {
+		scores[titleID] = float64(len(users)) / float64(len(audience))
+	}
+
+	return scores, nil
+}
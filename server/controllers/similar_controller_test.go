@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSimilarTitles(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/movie/:imdb_id/similar", asUser, app.GetSimilarMovies())
+
+	ctx := t.Context()
+	for _, movie := range []models.Movie{
+		rankedMovie("tt0000001", 2, "Action", "Drama"),
+		rankedMovie("tt0000002", 3, "Action"),
+		rankedMovie("tt0000003", 1, "Comedy"),
+		rankedMovie("tt0000004", 999, "Comedy"),
+		rankedMovie("tt0000005", 2, "Action"),
+	} {
+		_, err := app.Repos.Movies.Insert(ctx, movie)
+		assert.NoError(t, err)
+	}
+	tvShow := testTVShow("tt1000001", "Show", 1)
+	_, err := app.Repos.TVShows.Insert(ctx, tvShow)
+	assert.NoError(t, err)
+
+	// tt0000004 shares no genre with the source, only its audience
+	for _, rating := range []models.Rating{
+		{UserID: "user-2", ImdbID: "tt0000001", ContentType: models.ContentTypeMovie, Score: 5},
+		{UserID: "user-2", ImdbID: "tt0000004", ContentType: models.ContentTypeMovie, Score: 4},
+		{UserID: "user-2", ImdbID: "tt0000003", ContentType: models.ContentTypeMovie, Score: 2},
+	} {
+		assert.NoError(t, app.Repos.Ratings.Upsert(ctx, rating))
+	}
+	// Titles in the trash are never similar
+	assert.NoError(t, app.Repos.Movies.Delete(ctx, "tt0000005", "admin-1", 0))
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		want   []string
+	}{
+		{
+			name:   "same content type",
+			path:   "/movie/tt0000001/similar",
+			status: http.StatusOK,
+			want:   []string{"tt0000004", "tt0000002"},
+		},
+		{
+			name:   "all content types",
+			path:   "/movie/tt0000001/similar?content_type=all",
+			status: http.StatusOK,
+			want:   []string{"tt0000004", "tt1000001", "tt0000002"},
+		},
+		{
+			name:   "limit",
+			path:   "/movie/tt0000001/similar?content_type=all&limit=1",
+			status: http.StatusOK,
+			want:   []string{"tt0000004"},
+		},
+		{
+			name:   "unknown content type",
+			path:   "/movie/tt0000001/similar?content_type=book",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "source in the trash",
+			path:   "/movie/tt0000005/similar",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var similar []models.RecommendationItem
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &similar))
+			assert.Equal(t, tt.want, imdbIDsOf(similar))
+		})
+	}
+}
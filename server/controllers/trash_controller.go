@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (app *App) GetMovieTrash() gin.HandlerFunc {
+	return trashHandler(app, app.Repos.Movies, "movies")
+}
+
+func (app *App) RestoreMovie() gin.HandlerFunc {
+	return restoreHandler(app, app.Repos.Movies, "Movie", movieVersion)
+}
+
+func (app *App) GetTVShowTrash() gin.HandlerFunc {
+	return trashHandler(app, app.Repos.TVShows, "TV shows")
+}
+
+func (app *App) RestoreTVShow() gin.HandlerFunc {
+	return restoreHandler(app, app.Repos.TVShows, "TV show", tvShowVersion)
+}
+
+// trashHandler lists the deleted titles not purged yet, last deleted first.
+func trashHandler[T any](app *App, titles repository.TitleRepository[T], kind string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		deleted, err := titles.FindDeleted(ctx)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch deleted "+kind, err))
+			return
+		}
+
+		c.JSON(http.StatusOK, deleted)
+	}
+}
+
+// restoreHandler takes a title out of the trash.
+func restoreHandler[T any](app *App, titles repository.TitleRepository[T], kind string,
+	version func(*T) *int64) gin.HandlerFunc {
+
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		trashed, err := titles.Restore(ctx, imdbID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound(kind+" not found in the trash"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to restore "+kind, err))
+			return
+		}
+
+		recordRevision(app, c, titles, imdbID, models.RevisionRestore, &trashed)
+
+		title, err := titles.FindByImdbID(ctx, imdbID)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch "+kind, err))
+			return
+		}
+		c.Header("ETag", utils.ETag(*version(&title)))
+		c.JSON(http.StatusOK, title)
+	}
+}
+
+// StartTrashPurger purges the trash right away and then every configured
+// purge interval until ctx is cancelled. A purge in progress when ctx is
+// cancelled is allowed to complete, see WaitForWorkers.
+func (app *App) StartTrashPurger(ctx context.Context) {
+	app.workers.Add(1)
+	go func() {
+		defer app.workers.Done()
+
+		ticker := time.NewTicker(app.Config.Trash.PurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := app.PurgeTrash(context.WithoutCancel(ctx)); err != nil {
+				slog.Error("Error purging the trash", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PurgeTrash removes for good the titles deleted longer than the retention
+// period ago, along with the watchlist items and ratings pointing at them.
+// The dependents go first, so a purge failing halfway is completed by the
+// next one.
+func (app *App) PurgeTrash(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, app.Config.MongoOperationTimeout)
+	defer cancel()
+
+	cutoff := time.Now().Add(-app.Config.Trash.Retention)
+
+	if err := app.purgeTitles(ctx, app.Repos.Movies, cutoff); err != nil {
+		return err
+	}
+	return app.purgeTitles(ctx, app.Repos.TVShows, cutoff)
+}
+
+// Utility functions
+// ---------------------------------------------------------------------------------------
+
+func (app *App) purgeTitles(ctx context.Context, titles interface {
+	ContentType() string
+	DeletedBefore(ctx context.Context, before time.Time) ([]string, error)
+	Purge(ctx context.Context, imdbIDs []string) error
+}, cutoff time.Time) error {
+
+	imdbIDs, err := titles.DeletedBefore(ctx, cutoff)
+	if err != nil || len(imdbIDs) == 0 {
+		return err
+	}
+
+	if err := app.Repos.Watchlist.DeleteByTitles(ctx, titles.ContentType(), imdbIDs); err != nil {
+		return err
+	}
+	if err := app.Repos.Ratings.DeleteByTitles(ctx, titles.ContentType(), imdbIDs); err != nil {
+		return err
+	}
+	if err := titles.Purge(ctx, imdbIDs); err != nil {
+		return err
+	}
+
+	slog.Info("Purged the trash", "content_type", titles.ContentType(), "count", len(imdbIDs))
+	return nil
+}
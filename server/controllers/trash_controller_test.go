@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteAndRestoreMovie(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/movie/:imdb_id", app.GetMovie())
+	router.DELETE("/delete_movie/:imdb_id", asAdmin, app.DeleteMovie())
+	router.GET("/trash/movies", asAdmin, app.GetMovieTrash())
+	router.POST("/movie/:imdb_id/restore", asAdmin, app.RestoreMovie())
+	router.POST("/add_movie", asAdmin, app.AddMovie())
+
+	request := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	ctx := t.Context()
+	_, err := app.Repos.Movies.Insert(ctx, testMovie("tt0000001", "Test Movie"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, request("DELETE", "/delete_movie/tt0000001").Code)
+	assert.Equal(t, http.StatusNotFound, request("GET", "/movie/tt0000001").Code)
+
+	movies, err := app.Repos.Movies.FindAll(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, movies)
+
+	w := request("GET", "/trash/movies")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var trash []models.Movie
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &trash))
+	assert.Len(t, trash, 1)
+	assert.Equal(t, "admin-1", trash[0].DeletedBy)
+	assert.NotNil(t, trash[0].DeletedAt)
+
+	// The IMDB ID stays taken while the movie is in the trash
+	body, _ := json.Marshal(testMovie("tt0000001", "Another Movie"))
+	req, _ := http.NewRequest("POST", "/add_movie", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	var response apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Contains(t, response.Error.Message, "in the trash")
+
+	w = request("POST", "/movie/tt0000001/restore")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	assert.Equal(t, http.StatusOK, request("GET", "/movie/tt0000001").Code)
+
+	assert.Equal(t, http.StatusNotFound, request("POST", "/movie/tt0000001/restore").Code)
+
+	revisions, err := app.Repos.Revisions.FindByTitle(ctx, models.ContentTypeMovie, "tt0000001")
+	assert.NoError(t, err)
+	assert.Equal(t, models.RevisionRestore, revisions[0].Action)
+
+	// The restore is recorded against the movie as it was in the trash
+	restore, err := app.Repos.Revisions.FindByID(ctx, revisions[0].ID)
+	assert.NoError(t, err)
+	assert.Len(t, restore.Changes, 2)
+	assert.Equal(t, "deleted_at", restore.Changes[0].Field)
+	assert.Equal(t, models.FieldChange{Field: "deleted_by", Old: "admin-1"}, restore.Changes[1])
+}
+
+func TestTrashedTitlesLeaveWatchlistsAndRatings(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/watchlist", asUser, app.GetWatchlist())
+	router.GET("/ratings", asUser, app.GetUserRatings())
+
+	ctx := t.Context()
+	for _, imdbID := range []string{"tt0000001", "tt0000002"} {
+		_, err := app.Repos.Movies.Insert(ctx, testMovie(imdbID, "Movie "+imdbID))
+		assert.NoError(t, err)
+		_, err = app.Repos.Watchlist.Add(ctx, models.WatchlistItem{
+			UserID: "user-1", ImdbID: imdbID, ContentType: models.ContentTypeMovie,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, app.Repos.Ratings.Upsert(ctx, models.Rating{
+			UserID: "user-1", ImdbID: imdbID, ContentType: models.ContentTypeMovie, Score: 4,
+		}))
+	}
+	assert.NoError(t, app.Repos.Movies.Delete(ctx, "tt0000001", "admin-1", 0))
+
+	req, _ := http.NewRequest("GET", "/watchlist", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var watchlist []models.WatchlistItem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &watchlist))
+	assert.Len(t, watchlist, 1)
+	assert.Equal(t, "tt0000002", watchlist[0].ImdbID)
+
+	req, _ = http.NewRequest("GET", "/ratings", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var ratings []models.Rating
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ratings))
+	assert.Len(t, ratings, 1)
+	assert.Equal(t, "tt0000002", ratings[0].ImdbID)
+
+	// Restoring the movie brings its entries back
+	_, err := app.Repos.Movies.Restore(ctx, "tt0000001")
+	assert.NoError(t, err)
+	req, _ = http.NewRequest("GET", "/watchlist", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &watchlist))
+	assert.Len(t, watchlist, 2)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	_, app := setupTestRouter()
+	ctx := t.Context()
+
+	for _, imdbID := range []string{"tt0000001", "tt0000002"} {
+		_, err := app.Repos.Movies.Insert(ctx, testMovie(imdbID, "Movie "+imdbID))
+		assert.NoError(t, err)
+		_, err = app.Repos.Watchlist.Add(ctx, models.WatchlistItem{
+			UserID: "user-1", ImdbID: imdbID, ContentType: models.ContentTypeMovie,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, app.Repos.Ratings.Upsert(ctx, models.Rating{
+			UserID: "user-1", ImdbID: imdbID, ContentType: models.ContentTypeMovie, Score: 4,
+		}))
+	}
+	assert.NoError(t, app.Repos.Movies.Delete(ctx, "tt0000001", "admin-1", 0))
+
+	// Still within the retention period
+	assert.NoError(t, app.PurgeTrash(ctx))
+	trash, err := app.Repos.Movies.FindDeleted(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+
+	app.Config.Trash.Retention = time.Nanosecond
+	assert.NoError(t, app.PurgeTrash(ctx))
+
+	trash, err = app.Repos.Movies.FindDeleted(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, trash)
+	_, err = app.Repos.Movies.Restore(ctx, "tt0000001")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	watchlist, err := app.Repos.Watchlist.FindByUser(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, watchlist, 1)
+	assert.Equal(t, "tt0000002", watchlist[0].ImdbID)
+
+	ratings, err := app.Repos.Ratings.FindByUser(ctx, "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, ratings, 1)
+	assert.Equal(t, "tt0000002", ratings[0].ImdbID)
+}
@@ -1,39 +1,42 @@
 package controllers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/apierror"
 	"server/utils"
 
-	"server/database"
 	"server/models"
+	"server/repository"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
-	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
-var tvShowCollection *mongo.Collection = database.OpenCollection("tv_shows")
-var tvShowValidator = validator.New()
+// defaultUpcomingLimit is the number of upcoming episodes listed when the
+// request sets no limit.
+const defaultUpcomingLimit = 20
 
-func GetTVShows() gin.HandlerFunc {
+func (app *App) GetTVShows() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
-		defer cancel()
-
-		var tvShows []models.TVShow
-
-		cursor, err := tvShowCollection.Find(ctx, bson.M{})
+		between, err := parseDateRange(c)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch TV shows"})
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
 			return
 		}
-		defer cursor.Close(ctx)
-		if err = cursor.All(ctx, &tvShows); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to decode TV shows"})
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		tvShows, err := app.Repos.TVShows.FindBetween(ctx, between)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch TV shows", err))
 			return
 		}
 
@@ -41,339 +44,753 @@ func GetTVShows() gin.HandlerFunc {
 	}
 }
 
-func GetTVShow() gin.HandlerFunc {
+func (app *App) GetTVShow() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		imdbID := c.Param("imdb_id")
 		if imdbID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "IMDB ID is required"})
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
 			return
 		}
 
-		var tvShow models.TVShow
-		err := tvShowCollection.FindOne(ctx, bson.M{"imdb_id": imdbID}).Decode(&tvShow)
+		tvShow, err := app.Repos.TVShows.FindByImdbID(ctx, imdbID)
 		if err != nil {
-			if errors.Is(err, mongo.ErrNoDocuments) {
-				c.JSON(http.StatusNotFound, gin.H{"Error": "TV show not found"})
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("TV show not found"))
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch TV show"})
+			utils.RespondError(c, apierror.Internal("Failed to fetch TV show", err))
 			return
 		}
 
-		c.JSON(http.StatusOK, tvShow)
+		app.recordTitleEvent(c, tvShow.ImdbID, models.ContentTypeTVShow, models.TitleEventView)
+
+		respondWithETag(c, tvShow.Version, tvShow)
 	}
 }
 
-func GetTVShowSeason() gin.HandlerFunc {
+func (app *App) GetTVShowSeason() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		imdbID, seasonNumber, ok := seasonParams(c)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
-		imdbID := c.Param("imdb_id")
-		seasonNumber := c.Param("season_number")
+		season, err := app.Repos.TVShows.FindSeason(ctx, imdbID, seasonNumber)
+		if err != nil {
+			respondSeasonReadError(ctx, c, app, imdbID, err, "Season")
+			return
+		}
 
-		if imdbID == "" || seasonNumber == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "IMDB ID and season number are required"})
+		c.JSON(http.StatusOK, season)
+	}
+}
+
+func (app *App) GetEpisode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		imdbID, seasonNumber, ok := seasonParams(c)
+		if !ok {
+			return
+		}
+		episodeNumber, ok := episodeParam(c)
+		if !ok {
 			return
 		}
 
-		var tvShow models.TVShow
-		err := tvShowCollection.FindOne(ctx, bson.M{"imdb_id": imdbID}).Decode(&tvShow)
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		episode, err := app.Repos.TVShows.FindEpisode(ctx, imdbID, seasonNumber, episodeNumber)
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "TV show not found"})
+			respondSeasonReadError(ctx, c, app, imdbID, err, "Episode")
 			return
 		}
 
-		var foundSeason *models.Season
-		for _, season := range tvShow.Seasons {
-			if season.SeasonNumber == parseSeasonNumber(seasonNumber) {
-				foundSeason = &season
-				break
-			}
+		c.JSON(http.StatusOK, episode)
+	}
+}
+
+// GetUpcomingEpisodes lists the episodes of the ongoing shows airing from
+// today on, or within the from and to dates, soonest first.
+func (app *App) GetUpcomingEpisodes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var query repository.UpcomingQuery
+
+		var err error
+		if query.Between, err = parseDateRange(c); err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+		if query.Between.From.IsZero() {
+			query.Between.From = time.Now().UTC().Truncate(24 * time.Hour)
+		}
+		if query.Limit, err = parseLimitQuery(c, "limit", defaultUpcomingLimit); err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
+		}
+		if query.Skip, err = parseSkipQuery(c); err != nil {
+			utils.RespondError(c, apierror.BadRequest(err.Error()).Wrap(err))
+			return
 		}
 
-		if foundSeason == nil {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Season not found"})
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		episodes, err := app.Repos.TVShows.UpcomingEpisodes(ctx, query)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch upcoming episodes", err))
 			return
 		}
 
-		c.JSON(http.StatusOK, foundSeason)
+		c.JSON(http.StatusOK, episodes)
 	}
 }
 
-func AddTVShow() gin.HandlerFunc {
+func (app *App) AddTVShow() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		var tvShow models.TVShow
 		if err := c.ShouldBindJSON(&tvShow); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid input", "details": err.Error()})
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
 			return
 		}
-		if len(tvShow.Seasons) != tvShow.TotalSeasons {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Total seasons doesn't match the number of seasons provided"})
+		auditEntry(c).ImdbID = tvShow.ImdbID
+		if apiErr := checkSeasons(&tvShow); apiErr != nil {
+			utils.RespondError(c, apiErr)
 			return
 		}
 
-		if err := tvShowValidator.Struct(tvShow); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Validation failed", "details": err.Error()})
+		if err := validate.Struct(tvShow); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
 			return
 		}
 
-		var existingShow models.TVShow
-
-		err := tvShowCollection.FindOne(ctx, bson.M{"title": tvShow.Title}).Decode(&existingShow)
-		if err == nil {
-			c.JSON(http.StatusConflict, gin.H{"Error": "A TV show with this title already exists"})
+		exists, err := app.Repos.TVShows.ExistsWithTitle(ctx, tvShow.Title)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to check TV show", err))
+			return
+		}
+		if exists {
+			utils.RespondError(c, apierror.Conflict("A TV show with this title already exists"))
 			return
 		}
 
-		result, err := tvShowCollection.InsertOne(ctx, tvShow)
+		insertedID, err := app.Repos.TVShows.Insert(ctx, tvShow)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to add TV show"})
+			if errors.Is(err, repository.ErrInTrash) {
+				utils.RespondError(c, apierror.Conflict("A TV show with this IMDB ID is in the trash, restore it instead"))
+				return
+			}
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict("A TV show with this IMDB ID already exists"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to add TV show", err))
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		recordRevision(app, c, app.Repos.TVShows, tvShow.ImdbID, models.RevisionCreate, nil)
+
+		c.JSON(http.StatusCreated, gin.H{"InsertedID": insertedID})
 	}
 }
 
-func UpdateTVShow() gin.HandlerFunc {
+func (app *App) UpdateTVShow() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		imdbID := c.Param("imdb_id")
 		if imdbID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "IMDB ID is required"})
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
 			return
 		}
 
 		var tvShow models.TVShow
 		if err := c.ShouldBindJSON(&tvShow); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid input"})
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if tvShow.ImdbID != "" && tvShow.ImdbID != imdbID {
+			utils.RespondError(c, apierror.New(http.StatusBadRequest, apierror.CodeImmutableField,
+				"imdb_id cannot be changed"))
+			return
+		}
+		if apiErr := checkSeasons(&tvShow); apiErr != nil {
+			utils.RespondError(c, apiErr)
 			return
 		}
 
-		if err := tvShowValidator.Struct(tvShow); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Validation failed", "details": err.Error()})
+		if err := validate.Struct(tvShow); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
 			return
 		}
 
-		result, err := tvShowCollection.ReplaceOne(
-			ctx,
-			bson.M{"imdb_id": imdbID},
-			tvShow,
-		)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update TV show"})
+		before, ok := findForWrite(ctx, c, app.Repos.TVShows, imdbID, "TV show", tvShowVersion)
+		if !ok {
 			return
 		}
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "TV show not found"})
+
+		modifiedCount, err := app.Repos.TVShows.Replace(ctx, imdbID, tvShow, before.Version)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("TV show not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict("TV show"))
+				return
+			}
+			if errors.Is(err, repository.ErrInTrash) {
+				utils.RespondError(c, apierror.Conflict("A TV show with this IMDB ID is in the trash, restore it instead"))
+				return
+			}
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict("A TV show with this IMDB ID already exists"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to update TV show", err))
 			return
 		}
 
+		recordRevision(app, c, app.Repos.TVShows, imdbID, models.RevisionUpdate, &before)
+
 		c.JSON(http.StatusOK, gin.H{
 			"Message":        "TV show updated successfully",
-			"modified_count": result.ModifiedCount,
+			"modified_count": modifiedCount,
 		})
 	}
 }
 
-func AddSeason() gin.HandlerFunc {
+func (app *App) AddSeason() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		if !requireAdmin(c) {
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
 		imdbID := c.Param("imdb_id")
 		if imdbID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "ID is required"})
+			utils.RespondError(c, apierror.BadRequest("ID is required"))
 			return
 		}
 
 		var season models.Season
 		if err := c.ShouldBindJSON(&season); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid input"})
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if apiErr := checkEpisodeNumbers(season); apiErr != nil {
+			utils.RespondError(c, apiErr)
 			return
 		}
 
-		if err := tvShowValidator.Struct(season); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Validation failed", "details": err.Error()})
+		if err := validate.Struct(season); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
 			return
 		}
 
-		var tvShow models.TVShow
-		err := tvShowCollection.FindOne(ctx, bson.M{"imdb_id": imdbID}).Decode(&tvShow)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "Season already exists"})
+		tvShow, ok := findForWrite(ctx, c, app.Repos.TVShows, imdbID, "TV show", tvShowVersion)
+		if !ok {
 			return
 		}
-		for _, s := range tvShow.Seasons {
-			if s.SeasonNumber == season.SeasonNumber {
-				c.JSON(http.StatusConflict, gin.H{"Error": "Season already exists"})
+
+		if err := app.Repos.TVShows.AddSeason(ctx, imdbID, season, tvShow.Version); err != nil {
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict("Season already exists"))
 				return
 			}
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("TV show not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict("TV show"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to add season", err))
+			return
 		}
 
-		update := bson.M{
-			"$push": bson.M{"seasons": season},
-			"$inc":  bson.M{"total_seasons": 1},
+		recordRevision(app, c, app.Repos.TVShows, imdbID, models.RevisionUpdate, &tvShow)
+
+		c.JSON(http.StatusCreated, gin.H{
+			"Message":        "Season added successfully",
+			"modified_count": 1,
+		})
+	}
+}
+
+func (app *App) UpdateSeason() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
 		}
 
-		result, err := tvShowCollection.UpdateOne(ctx, bson.M{"imdb_id": imdbID}, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to add season"})
+		imdbID, seasonNumber, ok := seasonParams(c)
+		if !ok {
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"Message":        "Season added successfully",
-			"modified_count": result.ModifiedCount,
-		})
+		var season models.Season
+		if err := c.ShouldBindJSON(&season); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if season.SeasonNumber == 0 {
+			season.SeasonNumber = seasonNumber
+		}
+		if season.SeasonNumber != seasonNumber {
+			utils.RespondError(c, apierror.BadRequest("Season number doesn't match the URL"))
+			return
+		}
+		if apiErr := checkEpisodeNumbers(season); apiErr != nil {
+			utils.RespondError(c, apiErr)
+			return
+		}
+
+		if err := validate.Struct(season); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		before, _, ok := findSeasonForWrite(ctx, c, app, imdbID, seasonNumber)
+		if !ok {
+			return
+		}
+
+		err := app.Repos.TVShows.UpdateSeason(ctx, imdbID, season, before.Version)
+		if !finishSeasonWrite(app, c, imdbID, &before, err, "Failed to update season") {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"Message": "Season updated successfully"})
 	}
 }
 
-func DeleteTVShow() gin.HandlerFunc {
+func (app *App) DeleteSeason() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ctx, cancel := getDBContext()
+		if !requireAdmin(c) {
+			return
+		}
+
+		imdbID, seasonNumber, ok := seasonParams(c)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
-		imdbID := c.Param("imdb_id")
-		if imdbID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "IMDB ID is required"})
+		before, _, ok := findSeasonForWrite(ctx, c, app, imdbID, seasonNumber)
+		if !ok {
+			return
+		}
+		if len(before.Seasons) == 1 {
+			utils.RespondError(c, apierror.Conflict("A TV show must keep at least one season"))
 			return
 		}
 
-		result, err := tvShowCollection.DeleteOne(ctx, bson.M{"imdb_id": imdbID})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete TV show"})
+		err := app.Repos.TVShows.DeleteSeason(ctx, imdbID, seasonNumber, before.Version)
+		if !finishSeasonWrite(app, c, imdbID, &before, err, "Failed to delete season") {
 			return
 		}
-		if result.DeletedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "TV show not found"})
+
+		c.JSON(http.StatusOK, gin.H{"Message": "Season deleted successfully"})
+	}
+}
+
+func (app *App) AddEpisode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"Message": "TV show deleted successfully"})
+		imdbID, seasonNumber, ok := seasonParams(c)
+		if !ok {
+			return
+		}
+
+		var episode models.Episode
+		if err := c.ShouldBindJSON(&episode); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		before, season, ok := findSeasonForWrite(ctx, c, app, imdbID, seasonNumber)
+		if !ok {
+			return
+		}
+
+		// Episodes are appended, the number defaults to the next one
+		next := len(season.Episodes) + 1
+		if episode.EpisodeNumber == 0 {
+			episode.EpisodeNumber = next
+		}
+		if episode.EpisodeNumber < next {
+			utils.RespondError(c, apierror.Conflict("Episode already exists"))
+			return
+		}
+		if episode.EpisodeNumber > next {
+			utils.RespondError(c, apierror.BadRequest(fmt.Sprintf(
+				"Episode numbers must be contiguous, the next episode of season %d is %d", seasonNumber, next)))
+			return
+		}
+
+		if err := validate.Struct(episode); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
+		}
+
+		err := app.Repos.TVShows.AddEpisode(ctx, imdbID, seasonNumber, episode, before.Version)
+		if !finishSeasonWrite(app, c, imdbID, &before, err, "Failed to add episode") {
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"Message":        "Episode added successfully",
+			"episode_number": episode.EpisodeNumber,
+		})
 	}
 }
 
-func AdminTVShowReviewUpdate() gin.HandlerFunc {
+func (app *App) UpdateEpisode() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, err := utils.GetRoleFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Role not found in context"})
+		if !requireAdmin(c) {
 			return
 		}
-		if role != "ADMIN" {
-			c.JSON(http.StatusUnauthorized, gin.H{"Error": "User is not an ADMIN"})
+
+		imdbID, seasonNumber, ok := seasonParams(c)
+		if !ok {
+			return
+		}
+		episodeNumber, ok := episodeParam(c)
+		if !ok {
 			return
 		}
 
-		imdbID := c.Param("imdb_id")
-		if imdbID == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "IMDB ID required"})
+		var episode models.Episode
+		if err := c.ShouldBindJSON(&episode); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if episode.EpisodeNumber == 0 {
+			episode.EpisodeNumber = episodeNumber
+		}
+		if episode.EpisodeNumber != episodeNumber {
+			utils.RespondError(c, apierror.BadRequest("Episode number doesn't match the URL"))
 			return
 		}
 
-		var req struct {
-			AdminReview string `json:"admin_review"`
+		if err := validate.Struct(episode); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
 		}
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		before, _, ok := findEpisodeForWrite(ctx, c, app, imdbID, seasonNumber, episodeNumber)
+		if !ok {
 			return
 		}
 
-		sentiment, rankVal, err := getReviewRankingWithHugging(req.AdminReview)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error getting review ranking"})
+		err := app.Repos.TVShows.UpdateEpisode(ctx, imdbID, seasonNumber, episode, before.Version)
+		if !finishSeasonWrite(app, c, imdbID, &before, err, "Failed to update episode") {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"Message": "Episode updated successfully"})
+	}
+}
+
+func (app *App) DeleteEpisode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
 			return
 		}
 
-		filter := bson.M{"imdb_id": imdbID}
-		update := bson.M{
-			"$set": bson.M{
-				"admin_review": req.AdminReview,
-				"ranking": bson.M{
-					"ranking_value": rankVal,
-					"ranking_name":  sentiment,
-				},
-			},
+		imdbID, seasonNumber, ok := seasonParams(c)
+		if !ok {
+			return
+		}
+		episodeNumber, ok := episodeParam(c)
+		if !ok {
+			return
 		}
 
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
-		result, err := tvShowCollection.UpdateOne(ctx, filter, update)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error updating TV show"})
+		before, season, ok := findEpisodeForWrite(ctx, c, app, imdbID, seasonNumber, episodeNumber)
+		if !ok {
 			return
 		}
-		if result.MatchedCount == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"Error": "TV show not found"})
+		if len(season.Episodes) == 1 {
+			utils.RespondError(c, apierror.Conflict("A season must keep at least one episode, delete the season instead"))
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"ranking_name": sentiment,
-			"admin_review": req.AdminReview,
-		})
+		err := app.Repos.TVShows.DeleteEpisode(ctx, imdbID, seasonNumber, episodeNumber, before.Version)
+		if !finishSeasonWrite(app, c, imdbID, &before, err, "Failed to delete episode") {
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"Message": "Episode deleted successfully"})
 	}
 }
 
-func GetRecommendedTVShows() gin.HandlerFunc {
+func (app *App) DeleteTVShow() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userId, err := utils.GetUserIdFromContext(c)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "User ID not found"})
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
 			return
 		}
 
-		favouriteGenres, err := GetUsersFavouriteGenres(userId)
+		before, ok := findForWrite(ctx, c, app.Repos.TVShows, imdbID, "TV show", tvShowVersion)
+		if !ok {
+			return
+		}
+
+		userId, _ := utils.GetUserIdFromContext(c)
+		err := app.Repos.TVShows.Delete(ctx, imdbID, userId, before.Version)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("TV show not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict("TV show"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to delete TV show", err))
 			return
 		}
 
-		var recommendedLimit int64 = 5
-		findOptions := options.Find()
-		findOptions.SetSort(bson.D{{Key: "ranking.ranking_value", Value: 1}})
-		findOptions.SetLimit(recommendedLimit)
+		recordRevision(app, c, app.Repos.TVShows, imdbID, models.RevisionDelete, &before)
 
-		filter := bson.M{"genre.genre_name": bson.M{"$in": favouriteGenres}}
+		c.JSON(http.StatusOK, gin.H{"Message": "TV show deleted successfully"})
+	}
+}
 
-		ctx, cancel := getDBContext()
-		defer cancel()
+func (app *App) AdminTVShowReviewUpdate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
 
-		cursor, err := tvShowCollection.Find(ctx, filter, findOptions)
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID required"))
+			return
+		}
+
+		var req struct {
+			AdminReview string `json:"admin_review"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid request body").Wrap(err))
+			return
+		}
+
+		sentiment, rankVal, err := app.classifyReview(c, req.AdminReview)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Error fetching recommended TV shows"})
+			utils.RespondError(c, apierror.Internal("Error getting review ranking", err))
 			return
 		}
-		defer cursor.Close(ctx)
 
-		var recommendedShows []models.TVShow
-		if err := cursor.All(ctx, &recommendedShows); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		before, ok := findForWrite(ctx, c, app.Repos.TVShows, imdbID, "TV show", tvShowVersion)
+		if !ok {
 			return
 		}
 
-		c.JSON(http.StatusOK, recommendedShows)
+		err = app.Repos.TVShows.UpdateReview(ctx, imdbID, req.AdminReview, models.Ranking{
+			RankingValue: rankVal,
+			RankingName:  sentiment,
+		}, before.Version)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("TV show not found"))
+				return
+			}
+			if errors.Is(err, repository.ErrVersionConflict) {
+				utils.RespondError(c, versionConflict("TV show"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Error updating TV show", err))
+			return
+		}
+
+		recordRevision(app, c, app.Repos.TVShows, imdbID, models.RevisionReview, &before)
+
+		c.JSON(http.StatusOK, gin.H{
+			"ranking_name": sentiment,
+			"admin_review": req.AdminReview,
+		})
 	}
 }
 
+func (app *App) GetRecommendedTVShows() gin.HandlerFunc {
+	return recommendationHandler[models.TVShow](app, app.Repos.TVShows,
+		app.Config.Recommendations.TVShowLimit, "TV shows")
+}
+
 // Utility functions
 // ---------------------------------------------------------------------------------------
 
-func parseSeasonNumber(s string) int {
-	var num int
-	if _, err := fmt.Sscanf(s, "%d", &num); err != nil {
-		return 0
+// checkSeasons makes sure total_seasons counts the seasons of the show, that
+// no two seasons share a number and that every season numbers its episodes.
+func checkSeasons(tvShow *models.TVShow) *apierror.Error {
+	if tvShow.TotalSeasons != len(tvShow.Seasons) {
+		return apierror.BadRequest("Total seasons doesn't match the number of seasons provided")
+	}
+
+	seen := map[int]bool{}
+	for _, season := range tvShow.Seasons {
+		if seen[season.SeasonNumber] {
+			return apierror.BadRequest(fmt.Sprintf("Season %d is listed more than once", season.SeasonNumber))
+		}
+		seen[season.SeasonNumber] = true
+
+		if apiErr := checkEpisodeNumbers(season); apiErr != nil {
+			return apiErr
+		}
+	}
+	return nil
+}
+
+// checkEpisodeNumbers makes sure the episodes of the season are numbered
+// from 1 in order, without gaps or repeats.
+func checkEpisodeNumbers(season models.Season) *apierror.Error {
+	for i, episode := range season.Episodes {
+		if episode.EpisodeNumber != i+1 {
+			return apierror.BadRequest(fmt.Sprintf("Episodes of season %d must be numbered 1 to %d in order",
+				season.SeasonNumber, len(season.Episodes)))
+		}
+	}
+	return nil
+}
+
+// seasonParams reads the imdb_id and season_number of the path.
+func seasonParams(c *gin.Context) (string, int, bool) {
+	imdbID := c.Param("imdb_id")
+	if imdbID == "" {
+		utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
+		return "", 0, false
+	}
+	seasonNumber, ok := parsePathNumber(c, "season_number")
+	return imdbID, seasonNumber, ok
+}
+
+// episodeParam reads the episode_number of the path.
+func episodeParam(c *gin.Context) (int, bool) {
+	return parsePathNumber(c, "episode_number")
+}
+
+// findSeasonForWrite reads the TV show a season or episode write applies
+// to and the season, responding 404 when the show does not have it.
+func findSeasonForWrite(ctx context.Context, c *gin.Context, app *App, imdbID string,
+	seasonNumber int) (models.TVShow, models.Season, bool) {
+
+	tvShow, ok := findForWrite(ctx, c, app.Repos.TVShows, imdbID, "TV show", tvShowVersion)
+	if !ok {
+		return tvShow, models.Season{}, false
+	}
+	i := slices.IndexFunc(tvShow.Seasons, func(s models.Season) bool { return s.SeasonNumber == seasonNumber })
+	if i < 0 {
+		utils.RespondError(c, apierror.NotFound("Season not found"))
+		return tvShow, models.Season{}, false
+	}
+	return tvShow, tvShow.Seasons[i], true
+}
+
+// findEpisodeForWrite is findSeasonForWrite for a write to an episode of the
+// season.
+func findEpisodeForWrite(ctx context.Context, c *gin.Context, app *App, imdbID string,
+	seasonNumber, episodeNumber int) (models.TVShow, models.Season, bool) {
+
+	tvShow, season, ok := findSeasonForWrite(ctx, c, app, imdbID, seasonNumber)
+	if !ok {
+		return tvShow, season, false
+	}
+	if !slices.ContainsFunc(season.Episodes, func(e models.Episode) bool { return e.EpisodeNumber == episodeNumber }) {
+		utils.RespondError(c, apierror.NotFound("Episode not found"))
+		return tvShow, season, false
+	}
+	return tvShow, season, true
+}
+
+// finishSeasonWrite responds to the error of a season or episode write, or
+// records the revision it made, and returns whether it succeeded.
+func finishSeasonWrite(app *App, c *gin.Context, imdbID string, before *models.TVShow, err error,
+	message string) bool {
+
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			utils.RespondError(c, apierror.NotFound("Season or episode not found"))
+			return false
+		}
+		if errors.Is(err, repository.ErrVersionConflict) {
+			utils.RespondError(c, versionConflict("TV show"))
+			return false
+		}
+		utils.RespondError(c, apierror.Internal(message, err))
+		return false
+	}
+
+	recordRevision(app, c, app.Repos.TVShows, imdbID, models.RevisionUpdate, before)
+	return true
+}
+
+// respondSeasonReadError responds to the error reading a season or an
+// episode, telling a missing show from a missing season or episode.
+func respondSeasonReadError(ctx context.Context, c *gin.Context, app *App, imdbID string, err error, kind string) {
+	if !errors.Is(err, repository.ErrNotFound) {
+		utils.RespondError(c, apierror.Internal("Failed to fetch "+strings.ToLower(kind), err))
+		return
+	}
+
+	exists, err := app.Repos.TVShows.Exists(ctx, imdbID)
+	if err != nil {
+		utils.RespondError(c, apierror.Internal("Failed to fetch TV show", err))
+		return
+	}
+	if !exists {
+		utils.RespondError(c, apierror.NotFound("TV show not found"))
+		return
+	}
+	utils.RespondError(c, apierror.NotFound(kind+" not found"))
+}
+
+// parsePathNumber reads a season or episode number of the path, responding
+// 400 unless it is a positive integer.
+func parsePathNumber(c *gin.Context, key string) (int, bool) {
+	number, err := strconv.ParseUint(c.Param(key), 10, 31)
+	if err != nil || number == 0 {
+		utils.RespondError(c, apierror.BadRequest(key+" must be a positive integer"))
+		return 0, false
 	}
-	return num
+	return int(number), true
 }
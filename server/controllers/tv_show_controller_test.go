@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"server/models"
+	"server/repository"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTVShow builds a valid TV show with a season per count of episodes.
+func testTVShow(imdbID, title string, episodes ...int) models.TVShow {
+	tvShow := models.TVShow{
+		ImdbID:       imdbID,
+		Title:        title,
+		PosterPath:   "https://example.com/poster.jpg",
+		TrailerID:    "dQw4w9WgXcQ",
+		Genre:        []models.Genre{{GenreID: 1, GenreName: "Drama"}},
+		Ranking:      models.Ranking{RankingValue: 2, RankingName: "Good"},
+		Seasons:      []models.Season{},
+		TotalSeasons: len(episodes),
+		Status:       "Ongoing",
+		FirstAired:   models.NewDate(2024, 1, 1),
+	}
+	for i, count := range episodes {
+		season := models.Season{SeasonNumber: i + 1}
+		for n := 1; n <= count; n++ {
+			season.Episodes = append(season.Episodes, testEpisode(n))
+		}
+		tvShow.Seasons = append(tvShow.Seasons, season)
+	}
+	return tvShow
+}
+
+func testEpisode(number int) models.Episode {
+	return models.Episode{
+		EpisodeNumber: number,
+		EpisodeTitle:  fmt.Sprintf("Episode %d", number),
+		Duration:      45,
+		AirDate:       models.NewDate(2024, 1, 1),
+	}
+}
+
+func setupSeasonRouter(t *testing.T) (func(method, path, body string) *httptest.ResponseRecorder, *App) {
+	router, app := setupTestRouter()
+	router.POST("/tv_show/:imdb_id/add_season", asAdmin, app.AddSeason())
+	router.PUT("/tv_show/:imdb_id/season/:season_number", asAdmin, app.UpdateSeason())
+	router.DELETE("/tv_show/:imdb_id/season/:season_number", asAdmin, app.DeleteSeason())
+	router.POST("/tv_show/:imdb_id/season/:season_number/add_episode", asAdmin, app.AddEpisode())
+	router.PUT("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", asAdmin, app.UpdateEpisode())
+	router.DELETE("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", asAdmin, app.DeleteEpisode())
+
+	_, err := app.Repos.TVShows.Insert(t.Context(), testTVShow("tt1000001", "Test Show", 3, 2))
+	assert.NoError(t, err)
+
+	return func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}, app
+}
+
+func episodeTitles(season models.Season) []string {
+	titles := []string{}
+	for _, episode := range season.Episodes {
+		titles = append(titles, fmt.Sprintf("%d:%s", episode.EpisodeNumber, episode.EpisodeTitle))
+	}
+	return titles
+}
+
+func TestEpisodeCRUD(t *testing.T) {
+	request, app := setupSeasonRouter(t)
+	show := func() models.TVShow {
+		tvShow, err := app.Repos.TVShows.FindByImdbID(t.Context(), "tt1000001")
+		assert.NoError(t, err)
+		return tvShow
+	}
+
+	w := request("POST", "/tv_show/tt1000001/season/1/add_episode", `{"episode_title": "Finale", "duration": 50, "air_date": "2024-02-01"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"episode_number":4`)
+	assert.Equal(t, models.NewDate(2024, 2, 1), show().Seasons[0].Episodes[3].AirDate)
+
+	w = request("PUT", "/tv_show/tt1000001/season/1/episode/2", `{"episode_title": "Renamed", "duration": 45, "air_date": "2024-01-08"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"1:Episode 1", "2:Renamed", "3:Episode 3", "4:Finale"}, episodeTitles(show().Seasons[0]))
+
+	// The episodes after a deleted one move up
+	w = request("DELETE", "/tv_show/tt1000001/season/1/episode/2", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"1:Episode 1", "2:Episode 3", "3:Finale"}, episodeTitles(show().Seasons[0]))
+	assert.Equal(t, []string{"1:Episode 1", "2:Episode 2"}, episodeTitles(show().Seasons[1]))
+
+	season, _ := json.Marshal(models.Season{Episodes: []models.Episode{testEpisode(1)}})
+	w = request("PUT", "/tv_show/tt1000001/season/2", string(season))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, show().Seasons[1].Episodes, 1)
+
+	w = request("DELETE", "/tv_show/tt1000001/season/1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	tvShow := show()
+	assert.Equal(t, 1, tvShow.TotalSeasons)
+	assert.Equal(t, 2, tvShow.Seasons[0].SeasonNumber)
+	assert.Equal(t, int64(6), tvShow.Version)
+
+	revisions, err := app.Repos.Revisions.FindByTitle(t.Context(), models.ContentTypeTVShow, "tt1000001")
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 5)
+}
+
+func TestEpisodeCRUD_Rejected(t *testing.T) {
+	request, app := setupSeasonRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"Gap in the numbering", "POST", "/tv_show/tt1000001/season/1/add_episode", `{"episode_number": 5, "episode_title": "Later", "duration": 45, "air_date": "2024-01-01"}`, http.StatusBadRequest},
+		{"Episode already exists", "POST", "/tv_show/tt1000001/season/1/add_episode", `{"episode_number": 2, "episode_title": "Again", "duration": 45, "air_date": "2024-01-01"}`, http.StatusConflict},
+		{"Invalid episode", "POST", "/tv_show/tt1000001/season/1/add_episode", `{"episode_title": "No duration"}`, http.StatusBadRequest},
+		{"Invalid air date", "POST", "/tv_show/tt1000001/season/1/add_episode", `{"episode_title": "Later", "duration": 45, "air_date": "01/02/2024"}`, http.StatusBadRequest},
+		{"Missing season", "POST", "/tv_show/tt1000001/season/9/add_episode", `{"episode_title": "Lost", "duration": 45, "air_date": "2024-01-01"}`, http.StatusNotFound},
+		{"Missing episode", "PUT", "/tv_show/tt1000001/season/2/episode/3", `{"episode_title": "Lost", "duration": 45, "air_date": "2024-01-01"}`, http.StatusNotFound},
+		{"Episode number mismatch", "PUT", "/tv_show/tt1000001/season/1/episode/1", `{"episode_number": 2, "episode_title": "Moved", "duration": 45, "air_date": "2024-01-01"}`, http.StatusBadRequest},
+		{"Episodes out of order", "PUT", "/tv_show/tt1000001/season/1", `{"episodes": [{"episode_number": 2, "episode_title": "Two", "duration": 45, "air_date": "2024-01-01"}]}`, http.StatusBadRequest},
+		{"Season number mismatch", "PUT", "/tv_show/tt1000001/season/1", `{"season_number": 2, "episodes": []}`, http.StatusBadRequest},
+		{"Missing show", "DELETE", "/tv_show/tt9999999/season/1", "", http.StatusNotFound},
+		{"Invalid season number", "DELETE", "/tv_show/tt1000001/season/first", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	tvShow, err := app.Repos.TVShows.FindByImdbID(t.Context(), "tt1000001")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tvShow.Version)
+}
+
+func TestSeasonWrites_RequireAdmin(t *testing.T) {
+	router, app := setupTestRouter()
+	router.POST("/tv_show/:imdb_id/add_season", asUser, app.AddSeason())
+	router.PUT("/tv_show/:imdb_id/season/:season_number", asUser, app.UpdateSeason())
+	router.DELETE("/tv_show/:imdb_id/season/:season_number", asUser, app.DeleteSeason())
+	router.POST("/tv_show/:imdb_id/season/:season_number/add_episode", asUser, app.AddEpisode())
+	router.PUT("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", asUser, app.UpdateEpisode())
+	router.DELETE("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", asUser, app.DeleteEpisode())
+
+	_, err := app.Repos.TVShows.Insert(t.Context(), testTVShow("tt1000001", "Test Show", 3, 2))
+	assert.NoError(t, err)
+
+	episode, _ := json.Marshal(testEpisode(1))
+	season, _ := json.Marshal(models.Season{Episodes: []models.Episode{testEpisode(1)}})
+	newSeason, _ := json.Marshal(models.Season{SeasonNumber: 3, Episodes: []models.Episode{testEpisode(1)}})
+	for _, tt := range []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/tv_show/tt1000001/add_season", string(newSeason)},
+		{"PUT", "/tv_show/tt1000001/season/1", string(season)},
+		{"DELETE", "/tv_show/tt1000001/season/1", ""},
+		{"POST", "/tv_show/tt1000001/season/1/add_episode", string(episode)},
+		{"PUT", "/tv_show/tt1000001/season/1/episode/1", string(episode)},
+		{"DELETE", "/tv_show/tt1000001/season/1/episode/1", ""},
+	} {
+		req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code, "%s %s", tt.method, tt.path)
+	}
+
+	tvShow, err := app.Repos.TVShows.FindByImdbID(t.Context(), "tt1000001")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tvShow.Version)
+}
+
+func TestAddSeason(t *testing.T) {
+	request, app := setupSeasonRouter(t)
+	season := `{"season_number": 3, "episodes": [{"episode_number": 1, "episode_title": "Pilot", "duration": 45, "air_date": "2024-01-01"}]}`
+
+	assert.Equal(t, http.StatusCreated, request("POST", "/tv_show/tt1000001/add_season", season).Code)
+	show, err := app.Repos.TVShows.FindByImdbID(t.Context(), "tt1000001")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, show.TotalSeasons)
+	assert.Equal(t, int64(2), show.Version)
+
+	w := request("POST", "/tv_show/tt1000001/add_season", season)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Season already exists")
+
+	w = request("POST", "/tv_show/tt9999999/add_season", season)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "TV show not found")
+
+	// The write itself refuses a season added or a show changed since the read
+	err = app.Repos.TVShows.AddSeason(t.Context(), "tt1000001", models.Season{SeasonNumber: 3}, 0)
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	err = app.Repos.TVShows.AddSeason(t.Context(), "tt1000001", models.Season{SeasonNumber: 4}, 1)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+}
+
+func TestUpdateTVShow_ImdbIDMismatch(t *testing.T) {
+	router, app := setupTestRouter()
+	router.PUT("/update_tv_show/:imdb_id", app.UpdateTVShow())
+
+	_, err := app.Repos.TVShows.Insert(t.Context(), testTVShow("tt1000001", "Test Show", 1))
+	assert.NoError(t, err)
+
+	body, _ := json.Marshal(testTVShow("tt1000002", "Moved Show", 1))
+	req, _ := http.NewRequest("PUT", "/update_tv_show/tt1000001", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	tvShow, err := app.Repos.TVShows.FindByImdbID(t.Context(), "tt1000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Show", tvShow.Title)
+}
+
+func TestDeleteLastSeasonAndEpisode(t *testing.T) {
+	request, _ := setupSeasonRouter(t)
+
+	assert.Equal(t, http.StatusOK, request("DELETE", "/tv_show/tt1000001/season/2/episode/1", "").Code)
+	assert.Equal(t, http.StatusConflict, request("DELETE", "/tv_show/tt1000001/season/2/episode/1", "").Code)
+	assert.Equal(t, http.StatusOK, request("DELETE", "/tv_show/tt1000001/season/2", "").Code)
+	assert.Equal(t, http.StatusConflict, request("DELETE", "/tv_show/tt1000001/season/1", "").Code)
+}
+
+func TestCheckSeasons(t *testing.T) {
+	valid := testTVShow("tt1000001", "Test Show", 2, 1)
+	assert.Nil(t, checkSeasons(&valid))
+
+	duplicated := testTVShow("tt1000001", "Test Show", 1, 1)
+	duplicated.Seasons[1].SeasonNumber = 1
+	assert.Equal(t, "Season 1 is listed more than once", checkSeasons(&duplicated).Message)
+
+	renumbered := testTVShow("tt1000001", "Test Show", 3)
+	renumbered.Seasons[0].Episodes[2].EpisodeNumber = 2
+	assert.Equal(t, "Episodes of season 1 must be numbered 1 to 3 in order", checkSeasons(&renumbered).Message)
+}
+
+func TestGetSeasonAndEpisode(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/tv_show/:imdb_id/season/:season_number", app.GetTVShowSeason())
+	router.GET("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", app.GetEpisode())
+
+	_, err := app.Repos.TVShows.Insert(t.Context(), testTVShow("tt1000001", "Test Show", 3, 2))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		message string
+	}{
+		{"Season", "/tv_show/tt1000001/season/2", http.StatusOK, `"season_number":2`},
+		{"Episode", "/tv_show/tt1000001/season/1/episode/3", http.StatusOK, `"episode_title":"Episode 3"`},
+		{"Missing show", "/tv_show/tt9999999/season/1/episode/1", http.StatusNotFound, "TV show not found"},
+		{"Missing season", "/tv_show/tt1000001/season/3", http.StatusNotFound, "Season not found"},
+		{"Missing episode", "/tv_show/tt1000001/season/2/episode/3", http.StatusNotFound, "Episode not found"},
+		{"Trailing characters", "/tv_show/tt1000001/season/1abc", http.StatusBadRequest, "season_number must be a positive integer"},
+		{"Season zero", "/tv_show/tt1000001/season/0", http.StatusBadRequest, "season_number must be a positive integer"},
+		{"Negative episode", "/tv_show/tt1000001/season/1/episode/-1", http.StatusBadRequest, "episode_number must be a positive integer"},
+		{"Overflowing episode", "/tv_show/tt1000001/season/1/episode/99999999999", http.StatusBadRequest, "episode_number must be a positive integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.message)
+		})
+	}
+}
+
+func TestGetUpcomingEpisodes(t *testing.T) {
+	router, app := setupTestRouter()
+	router.GET("/episodes/upcoming", app.GetUpcomingEpisodes())
+
+	today := models.Date{Time: time.Now().UTC().Truncate(24 * time.Hour)}
+	nextWeek := models.Date{Time: today.AddDate(0, 0, 7)}
+
+	ongoing := testTVShow("tt1000001", "Ongoing Show", 3)
+	ongoing.Seasons[0].Episodes[1].AirDate = nextWeek
+	ongoing.Seasons[0].Episodes[2].AirDate = today
+	finished := testTVShow("tt1000002", "Finished Show", 1)
+	finished.Status = models.TVShowFinished
+	finished.Seasons[0].Episodes[0].AirDate = nextWeek
+	for _, tvShow := range []models.TVShow{ongoing, finished} {
+		_, err := app.Repos.TVShows.Insert(t.Context(), tvShow)
+		assert.NoError(t, err)
+	}
+
+	upcoming := func(query string) []models.UpcomingEpisode {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/episodes/upcoming"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var episodes []models.UpcomingEpisode
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &episodes))
+		return episodes
+	}
+
+	episodes := upcoming("")
+	assert.Len(t, episodes, 2)
+	assert.Equal(t, 3, episodes[0].EpisodeNumber)
+	assert.Equal(t, "Ongoing Show", episodes[0].Title)
+	assert.Equal(t, 1, episodes[0].SeasonNumber)
+	assert.True(t, nextWeek.Equal(episodes[1].AirDate.Time))
+
+	assert.Equal(t, 2, upcoming("?skip=1&limit=1")[0].EpisodeNumber)
+}
@@ -1,54 +1,50 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
-	"server/database"
+	"server/apierror"
+	"server/metrics"
 	"server/models"
+	"server/repository"
 	"server/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var usersCollection *mongo.Collection = database.OpenCollection("users")
-
-func RegisterUser() gin.HandlerFunc {
+func (app *App) RegisterUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var user models.User
 		if err := c.ShouldBindJSON(&user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid input"})
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
 			return
 		}
 
-		validate := validator.New()
 		if err := validate.Struct(user); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"Error":   "Validation failed",
-				"Details": err.Error(),
-			})
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
 			return
 		}
 
 		hashedPassword, err := HashPassword(user.Password)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Unable to hash password"})
+			utils.RespondError(c, apierror.Internal("Unable to hash password", err))
+			return
 		}
 
-		var ctx, cancel = getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
-		count, err := usersCollection.CountDocuments(ctx, bson.M{"email": user.Email})
+		exists, err := app.Repos.Users.ExistsWithEmail(ctx, user.Email)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to check user"})
+			utils.RespondError(c, apierror.Internal("Failed to check user", err))
 			return
 		}
-		if count > 0 {
-			c.JSON(http.StatusConflict, gin.H{"Error": "User already exists"})
+		if exists {
+			utils.RespondError(c, apierror.Conflict("User already exists"))
 			return
 		}
 
@@ -56,41 +52,57 @@ func RegisterUser() gin.HandlerFunc {
 		user.Password = hashedPassword
 		user.CreatedAt = time.Now()
 		user.UpdatedAt = time.Now()
-		result, err := usersCollection.InsertOne(ctx, user)
+		insertedID, err := app.Repos.Users.Insert(ctx, user)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create new user"})
+			// Registering twice at once gets past the check above
+			if errors.Is(err, repository.ErrDuplicate) {
+				utils.RespondError(c, apierror.Conflict("User already exists"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to create new user", err))
 			return
 		}
 
-		c.JSON(http.StatusCreated, result)
+		c.JSON(http.StatusCreated, gin.H{"InsertedID": insertedID})
 	}
 }
 
-func LoginUser() gin.HandlerFunc {
+func (app *App) LoginUser() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var userLogin models.UserLogin
 		if err := c.ShouldBindJSON(&userLogin); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid input"})
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
 			return
 		}
 
-		ctx, cancel := getDBContext()
+		ctx, cancel := app.dbContext(c)
 		defer cancel()
 
-		var foundUser models.User
-		err := usersCollection.FindOne(ctx, bson.M{"email": userLogin.Email}).Decode(&foundUser)
+		audit := auditEntry(c)
+		audit.Email = userLogin.Email
+
+		foundUser, err := app.Repos.Users.FindByEmail(ctx, userLogin.Email)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"Error": "Invalid email/password"})
+			if !errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.Internal("Failed to fetch the user", err))
+				return
+			}
+			audit.Action = models.AuditLoginFailed
+			metrics.AuthFailures.WithLabelValues(metrics.AuthInvalidCredentials).Inc()
+			utils.RespondError(c, apierror.Unauthorized("Invalid email/password").Wrap(err))
 			return
 		}
+		audit.ActorID, audit.ActorRole = foundUser.UserID, foundUser.Role
 
 		err = bcrypt.CompareHashAndPassword([]byte(foundUser.Password), []byte(userLogin.Password))
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"Error": "Incorrect email/password"})
+			audit.Action = models.AuditLoginFailed
+			metrics.AuthFailures.WithLabelValues(metrics.AuthInvalidCredentials).Inc()
+			utils.RespondError(c, apierror.Unauthorized("Incorrect email/password").Wrap(err))
 			return
 		}
 
-		token, refreshToken, err := utils.GenerateAllTokens(
+		token, refreshToken, err := app.Tokens.GenerateAllTokens(
 			foundUser.Email,
 			foundUser.FirstName,
 			foundUser.LastName,
@@ -98,13 +110,13 @@ func LoginUser() gin.HandlerFunc {
 			foundUser.UserID,
 		)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to generate token"})
+			utils.RespondError(c, apierror.Internal("Failed to generate token", err))
 			return
 		}
 
-		err = utils.UpdateAllTokens(foundUser.UserID, token, refreshToken)
+		err = app.Repos.Users.UpdateTokens(ctx, foundUser.UserID, token, refreshToken)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update the tokens"})
+			utils.RespondError(c, apierror.Internal("Failed to update the tokens", err))
 			return
 		}
 
@@ -121,6 +133,50 @@ func LoginUser() gin.HandlerFunc {
 	}
 }
 
+// UpdateUserRole makes the user of the path an ADMIN or a USER. Tokens
+// already issued keep the previous role until they expire.
+func (app *App) UpdateUserRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			return
+		}
+
+		userId := c.Param("user_id")
+		if userId == "" {
+			utils.RespondError(c, apierror.BadRequest("User ID is required"))
+			return
+		}
+
+		var req struct {
+			Role string `json:"role" validate:"required,oneof=ADMIN USER"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if err := validate.Struct(req); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		previous, err := app.Repos.Users.UpdateRole(ctx, userId, req.Role)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("User not found"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to update the role", err))
+			return
+		}
+		auditEntry(c).Detail = previous + " -> " + req.Role
+
+		c.JSON(http.StatusOK, gin.H{"user_id": userId, "role": req.Role})
+	}
+}
+
 // UTILITY FUNCTIONS
 // ------------------------------------------------------------------------------------------
 
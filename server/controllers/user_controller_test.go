@@ -2,38 +2,39 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"server/apierror"
+	"server/config"
+	"server/middleware"
 	"server/models"
+	"server/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"go.mongodb.org/mongo-driver/v2/bson"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func setupTestRouter() *gin.Engine {
+// setupTestRouter returns a router writing errors as the server does and an
+// App backed by empty in-memory repositories to build its handlers from.
+func setupTestRouter() (*gin.Engine, *App) {
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
-	return router
-}
-
-func cleanupTestUser(email string) {
-	ctx, cancel := getDBContext()
-	defer cancel()
-	usersCollection.DeleteOne(ctx, bson.M{"email": email})
+	router.Use(middleware.ErrorMiddleware())
+	return router, NewApp(config.Default(), nil, repository.NewMemoryRepositories())
 }
 
 func TestRegisterUser_Success(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/register", RegisterUser())
+	router, app := setupTestRouter()
+	router.POST("/register", app.RegisterUser())
 
 	testEmail := "test@example.com"
-	defer cleanupTestUser(testEmail)
 
 	user := models.User{
 		FirstName: "John",
@@ -52,19 +53,17 @@ func TestRegisterUser_Success(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 
-	ctx, cancel := getDBContext()
-	defer cancel()
+	ctx := t.Context()
 
-	var foundUser models.User
-	err := usersCollection.FindOne(ctx, bson.M{"email": testEmail}).Decode(&foundUser)
+	foundUser, err := app.Repos.Users.FindByEmail(ctx, testEmail)
 	assert.NoError(t, err)
 	assert.Equal(t, testEmail, foundUser.Email)
 	assert.NotEmpty(t, foundUser.UserID)
 }
 
 func TestRegisterUser_InvalidInput(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/register", RegisterUser())
+	router, app := setupTestRouter()
+	router.POST("/register", app.RegisterUser())
 
 	invalidJSON := []byte(`{"invalid": json}`)
 	req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(invalidJSON))
@@ -75,17 +74,17 @@ func TestRegisterUser_InvalidInput(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]interface{}
+	var response apierror.Response
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		return
 	}
-	assert.Contains(t, response, "Error")
+	assert.Equal(t, apierror.CodeInvalidInput, response.Error.Code)
 }
 
 func TestRegisterUser_ValidationFailed(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/register", RegisterUser())
+	router, app := setupTestRouter()
+	router.POST("/register", app.RegisterUser())
 
 	user := models.User{
 		FirstName: "",
@@ -103,21 +102,26 @@ func TestRegisterUser_ValidationFailed(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]interface{}
+	var response apierror.Response
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		return
 	}
-	assert.Contains(t, response, "Error")
-	assert.Equal(t, "Validation failed", response["Error"])
+	assert.Equal(t, apierror.CodeValidationFailed, response.Error.Code)
+	assert.Equal(t, "Validation failed", response.Error.Message)
+	assert.Contains(t, response.Error.Fields, apierror.FieldError{
+		Field: "email", Rule: "email", Message: "must be a valid email address",
+	})
+	assert.Contains(t, response.Error.Fields, apierror.FieldError{
+		Field: "first_name", Rule: "required", Message: "is required",
+	})
 }
 
 func TestRegisterUser_DuplicateEmail(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/register", RegisterUser())
+	router, app := setupTestRouter()
+	router.POST("/register", app.RegisterUser())
 
 	testEmail := "duplicate@example.com"
-	defer cleanupTestUser(testEmail)
 
 	user := models.User{
 		FirstName: "John",
@@ -142,22 +146,21 @@ func TestRegisterUser_DuplicateEmail(t *testing.T) {
 
 	assert.Equal(t, http.StatusConflict, w2.Code)
 
-	var response map[string]interface{}
+	var response apierror.Response
 	err := json.Unmarshal(w2.Body.Bytes(), &response)
 	if err != nil {
 		return
 	}
-	assert.Equal(t, "User already exists", response["Error"])
+	assert.Equal(t, "User already exists", response.Error.Message)
 }
 
 func TestLoginUser_Success(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/register", RegisterUser())
-	router.POST("/login", LoginUser())
+	router, app := setupTestRouter()
+	router.POST("/register", app.RegisterUser())
+	router.POST("/login", app.LoginUser())
 
 	testEmail := "login@example.com"
 	testPassword := "SecurePass123!"
-	defer cleanupTestUser(testEmail)
 
 	user := models.User{
 		FirstName: "Jane",
@@ -210,8 +213,8 @@ func TestLoginUser_Success(t *testing.T) {
 }
 
 func TestLoginUser_InvalidEmail(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/login", LoginUser())
+	router, app := setupTestRouter()
+	router.POST("/login", app.LoginUser())
 
 	loginData := models.UserLogin{
 		Email:    "nonexistent@example.com",
@@ -227,22 +230,46 @@ func TestLoginUser_InvalidEmail(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-	var response map[string]interface{}
+	var response apierror.Response
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		return
 	}
-	assert.Equal(t, "Invalid email/password", response["Error"])
+	assert.Equal(t, "Invalid email/password", response.Error.Message)
+}
+
+// unavailableUsers is a user repository whose lookups by email fail as they
+// do when MongoDB is down.
+type unavailableUsers struct {
+	repository.UserRepository
+}
+
+func (unavailableUsers) FindByEmail(context.Context, string) (models.User, error) {
+	return models.User{}, errors.New("server selection timeout")
+}
+
+func TestLoginUser_UsersUnavailable(t *testing.T) {
+	router, app := setupTestRouter()
+	app.Repos.Users = unavailableUsers{app.Repos.Users}
+	router.POST("/login", app.LoginUser())
+
+	jsonData, _ := json.Marshal(models.UserLogin{Email: "user@example.com", Password: "anyPassword123"})
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
 func TestLoginUser_IncorrectPassword(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/register", RegisterUser())
-	router.POST("/login", LoginUser())
+	router, app := setupTestRouter()
+	router.POST("/register", app.RegisterUser())
+	router.POST("/login", app.LoginUser())
 
 	testEmail := "wrongpass@example.com"
 	testPassword := "CorrectPass123!"
-	defer cleanupTestUser(testEmail)
 
 	user := models.User{
 		FirstName: "Test",
@@ -272,17 +299,17 @@ func TestLoginUser_IncorrectPassword(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, loginW.Code)
 
-	var response map[string]interface{}
+	var response apierror.Response
 	err := json.Unmarshal(loginW.Body.Bytes(), &response)
 	if err != nil {
 		return
 	}
-	assert.Equal(t, "Incorrect email/password", response["Error"])
+	assert.Equal(t, "Incorrect email/password", response.Error.Message)
 }
 
 func TestLoginUser_InvalidInput(t *testing.T) {
-	router := setupTestRouter()
-	router.POST("/login", LoginUser())
+	router, app := setupTestRouter()
+	router.POST("/login", app.LoginUser())
 
 	invalidJSON := []byte(`{"invalid": json}`)
 	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(invalidJSON))
@@ -293,12 +320,12 @@ func TestLoginUser_InvalidInput(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]interface{}
+	var response apierror.Response
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	if err != nil {
 		return
 	}
-	assert.Contains(t, response, "Error")
+	assert.Equal(t, apierror.CodeInvalidInput, response.Error.Code)
 }
 
 func TestHashPassword_Success(t *testing.T) {
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"server/apierror"
+	"server/models"
+	"server/repository"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (app *App) GetWatchlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		items, err := app.Repos.Watchlist.FindByUser(ctx, userId)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch watchlist", err))
+			return
+		}
+		items, err = liveEntries(ctx, app, items, func(item *models.WatchlistItem) (string, string) {
+			return item.ContentType, item.ImdbID
+		})
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to fetch watchlist", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, items)
+	}
+}
+
+func (app *App) AddToWatchlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		var item models.WatchlistItem
+		if err := c.ShouldBindJSON(&item); err != nil {
+			utils.RespondError(c, apierror.BadRequest("Invalid input").Wrap(err))
+			return
+		}
+		if err := validate.Struct(item); err != nil {
+			utils.RespondError(c, apierror.Validation("Validation failed", err))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		exists, err := app.titleExists(ctx, item.ContentType, item.ImdbID)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to check title", err))
+			return
+		}
+		if !exists {
+			utils.RespondError(c, apierror.NotFound("Title not found"))
+			return
+		}
+
+		item.UserID = userId
+		added, err := app.Repos.Watchlist.Add(ctx, item)
+		if err != nil {
+			utils.RespondError(c, apierror.Internal("Failed to add to watchlist", err))
+			return
+		}
+		if !added {
+			c.JSON(http.StatusOK, gin.H{"Message": "Title already in watchlist"})
+			return
+		}
+
+		app.recordTitleEvent(c, item.ImdbID, item.ContentType, models.TitleEventWatchlist)
+
+		c.JSON(http.StatusCreated, gin.H{"Message": "Title added to watchlist"})
+	}
+}
+
+func (app *App) RemoveFromWatchlist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, err := utils.GetUserIdFromContext(c)
+		if err != nil {
+			utils.RespondError(c, apierror.BadRequest("User Id not found").Wrap(err))
+			return
+		}
+
+		imdbID := c.Param("imdb_id")
+		if imdbID == "" {
+			utils.RespondError(c, apierror.BadRequest("IMDB ID is required"))
+			return
+		}
+
+		ctx, cancel := app.dbContext(c)
+		defer cancel()
+
+		err = app.Repos.Watchlist.Remove(ctx, userId, imdbID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				utils.RespondError(c, apierror.NotFound("Title not in watchlist"))
+				return
+			}
+			utils.RespondError(c, apierror.Internal("Failed to remove from watchlist", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"Message": "Title removed from watchlist"})
+	}
+}
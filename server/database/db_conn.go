@@ -1,50 +1,62 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"time"
 
-	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/v2/event"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 )
 
-var Client *mongo.Client = DBInstance()
+const pingTimeout = 5 * time.Second
 
-func DBInstance() *mongo.Client {
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Println("Warning: Trouble finding .env file")
-	}
+// Connect creates the MongoDB client for the given URI and pings the server
+// until it answers, trying again up to retries times, retryInterval apart.
+// The client is disconnected when the server never answers. The monitor, if
+// not nil, is notified of every command.
+func Connect(ctx context.Context, uri string, retries int, retryInterval time.Duration,
+	monitor *event.CommandMonitor) (*mongo.Client, error) {
 
-	MongoDb := os.Getenv("MONGODB_URI")
-	if MongoDb == "" {
-		log.Fatal("Error: MONGODB_URI not found")
+	if uri == "" {
+		return nil, errors.New("MONGODB_URI not found")
 	}
 
-	fmt.Println("MongoDB URI: ", MongoDb)
-	clientOptions := options.Client().ApplyURI(MongoDb)
+	clientOptions := options.Client().ApplyURI(uri).SetMonitor(monitor)
 	client, err := mongo.Connect(clientOptions)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 
-	return client
-}
+	err = Ping(ctx, client)
+	for attempt := 1; err != nil && attempt <= retries && ctx.Err() == nil; attempt++ {
+		slog.Warn("MongoDB ping failed, retrying", "in", retryInterval.String(),
+			"attempt", attempt, "retries", retries, "error", err)
 
-func OpenCollection(collectionName string) *mongo.Collection {
-	err := godotenv.Load(".env")
-	if err != nil {
-		log.Println("Warning: Trouble finding .env file")
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+			err = Ping(ctx, client)
+		}
+	}
+	if err == nil {
+		return client, nil
 	}
 
-	dbName := os.Getenv("DATABASE_NAME")
-	fmt.Println("Database name: ", dbName)
-	collection := Client.Database(dbName).Collection(collectionName)
-	if collection == nil {
-		return nil
+	if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
+		slog.Error("Error disconnecting from MongoDB", "error", disconnectErr)
 	}
+	return nil, fmt.Errorf("MongoDB is unreachable: %w", err)
+}
+
+// Ping checks the primary answers within pingTimeout.
+func Ping(ctx context.Context, client *mongo.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
 
-	return collection
+	return client.Ping(ctx, readpref.Primary())
 }
@@ -0,0 +1,59 @@
+// Package logging configures the structured JSON logger of the application
+// and carries the request id through contexts, so every record logged with
+// a request context is tagged with it.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+type requestIDKey struct{}
+
+// level is shared by every handler Setup installs, so it can be changed once
+// the configuration is loaded.
+var level slog.LevelVar
+
+// Setup makes a JSON logger writing to stdout the default slog logger, which
+// the standard log package then writes through as well.
+func Setup() {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: &level})
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// SetLevel changes the minimum level logged.
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// WithRequestID returns a copy of ctx carrying the request id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request id carried by ctx, "" when there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler adds the request id of the context to the records.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -1,9 +1,23 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 	"time"
 
+	"server/config"
+	"server/controllers"
+	"server/database"
+	"server/logging"
+	"server/metrics"
+	"server/middleware"
+	"server/repository"
 	"server/routes"
 
 	"github.com/gin-contrib/cors"
@@ -12,25 +26,127 @@ import (
 
 func main() {
 	// entry point of the application
-	router := gin.Default()
+	logging.Setup()
 
-	config := cors.Config{}
-	config.AllowAllOrigins = true
-	config.AllowMethods = []string{
+	cfg, err := config.Load()
+	if err != nil {
+		fatal("Invalid configuration", err)
+	}
+	logging.SetLevel(cfg.LogLevel)
+
+	// Cancelled on the first SIGINT or SIGTERM, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client, err := database.Connect(ctx, cfg.MongoURI, cfg.MongoConnectRetries, cfg.MongoRetryInterval,
+		metrics.MongoMonitor())
+	if err != nil {
+		fatal("Failed to connect to MongoDB", err)
+	}
+	db := client.Database(cfg.DatabaseName)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := runMigrateCommand(ctx, db, os.Args[2:])
+		if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
+			slog.Error("Error disconnecting from MongoDB", "error", disconnectErr)
+		}
+		if err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+	if cfg.MigrateOnStartup {
+		if err := applyMigrations(ctx, db); err != nil {
+			fatal("Migration failed", err)
+		}
+	}
+	repos := repository.NewMongoRepositories(db)
+	app := controllers.NewApp(cfg, client, repos)
+
+	if len(os.Args) > 1 && slices.Contains([]string{"import", "export", "restore"}, os.Args[1]) {
+		err := runCatalogCommand(ctx, app, os.Args[1], os.Args[2:])
+		if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
+			slog.Error("Error disconnecting from MongoDB", "error", disconnectErr)
+		}
+		if err != nil {
+			fatal("The "+os.Args[1]+" command failed", err)
+		}
+		return
+	}
+
+	router := gin.New()
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.LoggingMiddleware())
+	router.Use(middleware.RecoveryMiddleware())
+
+	corsConfig := cors.Config{}
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AllowMethods = []string{
 		"GET", "POST", "PUT", "PATCH", "DELETE",
 	}
-	config.AllowHeaders = []string{
-		"Origin", "Content-Type", "Authorization",
+	corsConfig.AllowHeaders = []string{
+		"Origin", "Content-Type", "Authorization", "If-Match", "If-None-Match", middleware.RequestIDHeader,
 	}
-	config.ExposeHeaders = []string{"Content-Length"}
-	config.MaxAge = 12 * time.Hour
+	corsConfig.ExposeHeaders = []string{"Content-Length", "ETag", middleware.RequestIDHeader}
+	corsConfig.MaxAge = 12 * time.Hour
+
+	router.Use(cors.New(corsConfig))
+	router.Use(middleware.MetricsMiddleware())
+	router.Use(middleware.ErrorMiddleware())
+	router.NoRoute(middleware.NoRoute())
+
+	routes.SetupUnprotectedRoutes(router, app)
+	routes.SetupProtectedRoutes(router, app)
 
-	router.Use(cors.New(config))
-	router.Use(gin.Logger())
+	app.StartTrendingChartRefresher(ctx)
+	app.StartTrashPurger(ctx)
 
-	routes.SetupUnprotectedRoutes(router)
-	routes.SetupProtectedRoutes(router)
-	if err := router.Run(":8080"); err != nil {
-		fmt.Println("Failed to start the server", err)
+	server := &http.Server{
+		Addr:         cfg.Server.Addr,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Server listening", "addr", cfg.Server.Addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		slog.Error("Server stopped unexpectedly", "error", err)
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	}
+	stop()
+
+	shutdown(server, app, cfg.Server.ShutdownTimeout)
+}
+
+// shutdown stops accepting requests, waits for the in-flight ones and the
+// background workers to finish within timeout, then disconnects from MongoDB.
+func shutdown(server *http.Server, app *controllers.App, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Error draining requests", "error", err)
+	}
+	if err := app.WaitForWorkers(ctx); err != nil {
+		slog.Error("Error waiting for background workers", "error", err)
+	}
+	if err := app.Client.Disconnect(ctx); err != nil {
+		slog.Error("Error disconnecting from MongoDB", "error", err)
+	}
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
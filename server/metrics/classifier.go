@@ -0,0 +1,13 @@
+package metrics
+
+import "time"
+
+// ObserveClassification records a classification made by provider that
+// started at start and failed when err is not nil.
+func ObserveClassification(provider string, start time.Time, err error) {
+	ClassifierRequests.WithLabelValues(provider).Inc()
+	ClassifierDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	if err != nil {
+		ClassifierFailures.WithLabelValues(provider).Inc()
+	}
+}
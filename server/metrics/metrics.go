@@ -0,0 +1,69 @@
+// Package metrics defines the Prometheus collectors of the API and the
+// helpers that feed them. Collectors are registered on the default registry,
+// which /metrics serves.
+package metrics
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "loomi"
+
+var (
+	// HTTPRequestDuration is labelled with the route template, not the raw
+	// path, to keep the number of series bounded.
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	MongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_operation_duration_seconds",
+		Help:      "Duration of the MongoDB commands by collection, command and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"collection", "command", "outcome"})
+
+	ClassifierRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "classifier_requests_total",
+		Help:      "Admin review classifications requested by provider.",
+	}, []string{"provider"})
+
+	ClassifierFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "classifier_failures_total",
+		Help:      "Admin review classifications that failed by provider.",
+	}, []string{"provider"})
+
+	ClassifierDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "classifier_request_duration_seconds",
+		Help:      "Duration of the admin review classifications by provider.",
+		Buckets:   []float64{.1, .25, .5, 1, 2.5, 5, 10, 20, 30},
+	}, []string{"provider"})
+
+	AuthFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_failures_total",
+		Help:      "Rejected authentication and authorization attempts by reason.",
+	}, []string{"reason"})
+)
+
+// Reasons an authentication or authorization attempt is rejected.
+const (
+	AuthMissingToken       = "missing_token"
+	AuthInvalidToken       = "invalid_token"
+	AuthInvalidCredentials = "invalid_credentials"
+	AuthForbidden          = "forbidden"
+)
+
+// Handler serves the collectors in the Prometheus text format.
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
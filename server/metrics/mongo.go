@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+)
+
+// MongoMonitor returns a command monitor that times every command run on a
+// collection. Commands that do not target one, as ping or hello, are not
+// recorded.
+func MongoMonitor() *event.CommandMonitor {
+	// The finished events do not carry the command, so the collection is
+	// remembered by request id between the started and finished events
+	var collections sync.Map
+
+	finished := func(requestID int64, commandName, outcome string, seconds float64) {
+		collection, ok := collections.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		MongoOperationDuration.WithLabelValues(collection.(string), commandName, outcome).Observe(seconds)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			if collection := commandCollection(e.CommandName, e.Command); collection != "" {
+				collections.Store(e.RequestID, collection)
+			}
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.RequestID, e.CommandName, "success", e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finished(e.RequestID, e.CommandName, "failure", e.Duration.Seconds())
+		},
+	}
+}
+
+// commandCollection returns the collection a command runs on, which is the
+// value of its first element except for getMore.
+func commandCollection(commandName string, command bson.Raw) string {
+	if commandName == "getMore" {
+		collection, _ := command.Lookup("collection").StringValueOK()
+		return collection
+	}
+
+	element, err := command.IndexErr(0)
+	if err != nil {
+		return ""
+	}
+	collection, _ := element.Value().StringValueOK()
+	return collection
+}
@@ -0,0 +1,24 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func rawCommand(t *testing.T, command bson.D) bson.Raw {
+	raw, err := bson.Marshal(command)
+	assert.NoError(t, err)
+	return raw
+}
+
+func TestCommandCollection(t *testing.T) {
+	find := rawCommand(t, bson.D{{Key: "find", Value: "movies"}, {Key: "filter", Value: bson.D{}}})
+	getMore := rawCommand(t, bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "tv_shows"}})
+	ping := rawCommand(t, bson.D{{Key: "ping", Value: 1}})
+
+	assert.Equal(t, "movies", commandCollection("find", find))
+	assert.Equal(t, "tv_shows", commandCollection("getMore", getMore))
+	assert.Equal(t, "", commandCollection("ping", ping))
+}
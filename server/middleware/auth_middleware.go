@@ -1,30 +1,31 @@
 package middleware
 
 import (
-	"net/http"
+	"server/apierror"
+	"server/metrics"
 	"server/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(tokens *utils.TokenService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token, err := utils.GetAccessToken(c)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"Error": err.Error()})
-			c.Abort()
+			metrics.AuthFailures.WithLabelValues(metrics.AuthMissingToken).Inc()
+			utils.RespondError(c, apierror.Unauthorized(err.Error()).Wrap(err))
 			return
 		}
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"Error": "No token provided"})
-			c.Abort()
+			metrics.AuthFailures.WithLabelValues(metrics.AuthMissingToken).Inc()
+			utils.RespondError(c, apierror.Unauthorized("No token provided"))
 			return
 		}
 
-		claims, err := utils.ValidateToken(token)
+		claims, err := tokens.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"Error": "Invalid token"})
-			c.Abort()
+			metrics.AuthFailures.WithLabelValues(metrics.AuthInvalidToken).Inc()
+			utils.RespondError(c, apierror.Unauthorized("Invalid token").Wrap(err))
 			return
 		}
 		c.Set("userId", claims.UserID)
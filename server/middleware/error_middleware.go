@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"server/apierror"
+	"server/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorMiddleware writes the last error the handlers attached to the context
+// as the response, unless they already wrote one. Errors that are not an
+// *apierror.Error are answered as internal errors, without their message.
+func ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		writeError(c, apierror.From(c.Errors.Last().Err))
+	}
+}
+
+// NoRoute answers the requests that match no route with a not_found error.
+func NoRoute() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = c.Error(apierror.NotFound("Route not found"))
+	}
+}
+
+func writeError(c *gin.Context, apiErr *apierror.Error) {
+	c.AbortWithStatusJSON(apiErr.Status, apierror.Response{
+		Error: apierror.ResponseBody{
+			Error:     apiErr,
+			RequestID: logging.RequestID(c.Request.Context()),
+		},
+	})
+}
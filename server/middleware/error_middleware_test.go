@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/apierror"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveError(t *testing.T, handler gin.HandlerFunc) (int, apierror.Response) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RecoveryMiddleware(), ErrorMiddleware())
+	router.GET("/", handler)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func TestErrorMiddleware_HidesUnknownErrors(t *testing.T) {
+	code, response := serveError(t, func(c *gin.Context) {
+		_ = c.Error(errors.New("connection refused"))
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, apierror.CodeInternal, response.Error.Code)
+	assert.Equal(t, "Internal server error", response.Error.Message)
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	code, response := serveError(t, func(c *gin.Context) {
+		panic("boom")
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, apierror.CodeInternal, response.Error.Code)
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"server/apierror"
+	"server/logging"
+	"server/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRequestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware(), ErrorMiddleware())
+	router.GET("/fail", func(c *gin.Context) {
+		utils.RespondError(c, apierror.NotFound("Not found"))
+	})
+	router.GET("/echo", func(c *gin.Context) {
+		c.String(http.StatusOK, logging.RequestID(c.Request.Context()))
+	})
+	return router
+}
+
+func TestRequestIDMiddleware_KeepsClientID(t *testing.T) {
+	router := setupRequestIDRouter()
+
+	req, _ := http.NewRequest("GET", "/fail", nil)
+	req.Header.Set(RequestIDHeader, "client-id-123")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response apierror.Response
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "client-id-123", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "client-id-123", response.Error.RequestID)
+	assert.Equal(t, apierror.CodeNotFound, response.Error.Code)
+}
+
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	router := setupRequestIDRouter()
+
+	req, _ := http.NewRequest("GET", "/echo", nil)
+	req.Header.Set(RequestIDHeader, "not a valid id\n")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	requestID := w.Header().Get(RequestIDHeader)
+	assert.Len(t, requestID, 36)
+	assert.Equal(t, requestID, w.Body.String())
+}
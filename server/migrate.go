@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"server/migrations"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// runMigrateCommand runs the migrate subcommand:
+//
+//	server migrate [up]   applies the pending migrations
+//	server migrate status lists every migration and when it was applied
+func runMigrateCommand(ctx context.Context, db *mongo.Database, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		return applyMigrations(ctx, db)
+	case "status":
+		return printMigrationStatus(ctx, db)
+	default:
+		return errors.New("usage: server migrate [up|status]")
+	}
+}
+
+func applyMigrations(ctx context.Context, db *mongo.Database) error {
+	applied, err := migrations.Up(ctx, db)
+	for _, migration := range applied {
+		slog.Info("Applied migration", "version", migration.Version, "description", migration.Description)
+	}
+	return err
+}
+
+func printMigrationStatus(ctx context.Context, db *mongo.Database) error {
+	records, err := migrations.Applied(ctx, db)
+	if err != nil {
+		return err
+	}
+	appliedAt := make(map[int]string, len(records))
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt.Format("2006-01-02 15:04:05")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tAPPLIED AT\tDESCRIPTION")
+	for _, migration := range migrations.All {
+		status, applied := appliedAt[migration.Version]
+		if !applied {
+			status = "pending"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", migration.Version, status, migration.Description)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,52 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"server/repository"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, index := range repository.Indexes {
+		if err := ensureIndex(ctx, db, index); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureIndex creates the index, first dropping an index of the same name
+// that differs in uniqueness, as the non-unique ones earlier versions
+// created at startup. Creating a unique index fails when the collection
+// already holds duplicates, which have to be resolved by hand.
+func ensureIndex(ctx context.Context, db *mongo.Database, index repository.IndexSpec) error {
+	indexes := db.Collection(index.Collection).Indexes()
+
+	specs, err := indexes.ListSpecifications(ctx)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		unique := spec.Unique != nil && *spec.Unique
+		if spec.Name == index.Name && unique != index.Unique {
+			if err := indexes.DropOne(ctx, spec.Name); err != nil {
+				return fmt.Errorf("dropping index %s on %s: %w", index.Name, index.Collection, err)
+			}
+		}
+	}
+
+	opts := options.Index().SetName(index.Name)
+	if index.Unique {
+		opts.SetUnique(true)
+	}
+	if _, err := indexes.CreateOne(ctx, mongo.IndexModel{Keys: index.Keys, Options: opts}); err != nil {
+		return fmt.Errorf("creating index %s on %s: %w", index.Name, index.Collection, err)
+	}
+
+	return nil
+}
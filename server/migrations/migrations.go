@@ -0,0 +1,107 @@
+// Package migrations evolves the MongoDB schema: indexes, validators and
+// stored data. Every migration runs once, in version order, and is recorded
+// in the migrations collection when it succeeds. A migration may run again
+// if the process stops before recording it, or when two instances start at
+// once, so Up must be safe to repeat.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const collectionName = "migrations"
+
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// Record is stored in the migrations collection for each applied migration.
+type Record struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+// All lists every migration, in version order. New migrations are appended
+// with the next version; applied ones are never edited.
+var All = []Migration{
+	{Version: 1, Description: "Create unique, lookup, genre and ranking indexes", Up: createIndexes},
+	{Version: 2, Description: "Validate movies, TV shows and users with JSON schemas", Up: addSchemaValidators},
+	{Version: 3, Description: "Start every movie and TV show at version 1", Up: addTitleVersions},
+	{Version: 4, Description: "Index the revision history of titles", Up: createIndexes},
+	{Version: 5, Description: "Index the audit log by time and actor", Up: createIndexes},
+	{Version: 6, Description: "Store release, first aired and air dates as dates", Up: convertTitleDates},
+}
+
+// Applied returns the records of the applied migrations, in version order.
+func Applied(ctx context.Context, db *mongo.Database) ([]Record, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := db.Collection(collectionName).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []Record{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// Pending returns the migrations not applied yet, in version order.
+func Pending(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	records, err := Applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]bool, len(records))
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+
+	pending := []Migration{}
+	for _, migration := range All {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies the pending migrations in order, stopping at the first that
+// fails, and returns the ones applied.
+func Up(ctx context.Context, db *mongo.Database) ([]Migration, error) {
+	pending, err := Pending(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	for _, migration := range pending {
+		if err := migration.Up(ctx, db); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		record := Record{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+		_, err := db.Collection(collectionName).InsertOne(ctx, record)
+		// Another instance applied it at the same time
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return applied, fmt.Errorf("recording migration %d: %w", migration.Version, err)
+		}
+		applied = append(applied, migration)
+	}
+
+	return applied, nil
+}
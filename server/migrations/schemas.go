@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// The schemas only hold what every document must have. They leave out the
+// date fields, whose type a later migration may change, and allow other
+// fields so adding one needs no migration.
+var (
+	intTypes = bson.A{"int", "long"}
+
+	genreSchema = bson.M{
+		"bsonType": "object",
+		"required": bson.A{"genre_id", "genre_name"},
+		"properties": bson.M{
+			"genre_id":   bson.M{"bsonType": intTypes},
+			"genre_name": bson.M{"bsonType": "string"},
+		},
+	}
+
+	rankingSchema = bson.M{
+		"bsonType": "object",
+		"required": bson.A{"ranking_value", "ranking_name"},
+		"properties": bson.M{
+			"ranking_value": bson.M{"bsonType": intTypes},
+			"ranking_name":  bson.M{"bsonType": "string"},
+		},
+	}
+
+	movieSchema = bson.M{
+		"bsonType": "object",
+		"required": bson.A{"imdb_id", "title", "genre", "ranking"},
+		"properties": bson.M{
+			"imdb_id": bson.M{"bsonType": "string", "minLength": 1},
+			"title":   bson.M{"bsonType": "string", "minLength": 1},
+			"genre":   bson.M{"bsonType": "array", "items": genreSchema},
+			"ranking": rankingSchema,
+		},
+	}
+
+	tvShowSchema = bson.M{
+		"bsonType": "object",
+		"required": bson.A{"imdb_id", "title", "genre", "ranking", "seasons", "total_seasons", "status"},
+		"properties": bson.M{
+			"imdb_id":       bson.M{"bsonType": "string", "minLength": 1},
+			"title":         bson.M{"bsonType": "string", "minLength": 1},
+			"genre":         bson.M{"bsonType": "array", "items": genreSchema},
+			"ranking":       rankingSchema,
+			"seasons":       bson.M{"bsonType": "array"},
+			"total_seasons": bson.M{"bsonType": intTypes, "minimum": 0},
+			"status":        bson.M{"enum": bson.A{"Ongoing", "Finished", "Cancelled"}},
+		},
+	}
+
+	userSchema = bson.M{
+		"bsonType": "object",
+		"required": bson.A{"user_id", "email", "password", "role"},
+		"properties": bson.M{
+			"user_id":  bson.M{"bsonType": "string", "minLength": 1},
+			"email":    bson.M{"bsonType": "string", "pattern": "^[^@]+@[^@]+$"},
+			"password": bson.M{"bsonType": "string", "minLength": 1},
+			"role":     bson.M{"enum": bson.A{"ADMIN", "USER"}},
+		},
+	}
+)
+
+var schemas = []struct {
+	collection string
+	schema     bson.M
+}{
+	{"movies", movieSchema},
+	{"tv_shows", tvShowSchema},
+	{"users", userSchema},
+}
+
+// addSchemaValidators sets the validators with the moderate level, so
+// existing documents that do not match can still be updated until fixed.
+func addSchemaValidators(ctx context.Context, db *mongo.Database) error {
+	collections, err := db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range schemas {
+		validator := bson.M{"$jsonSchema": s.schema}
+
+		if slices.Contains(collections, s.collection) {
+			err = db.RunCommand(ctx, bson.D{
+				{Key: "collMod", Value: s.collection},
+				{Key: "validator", Value: validator},
+				{Key: "validationLevel", Value: "moderate"},
+				{Key: "validationAction", Value: "error"},
+			}).Err()
+		} else {
+			opts := options.CreateCollection().
+				SetValidator(validator).
+				SetValidationLevel("moderate").
+				SetValidationAction("error")
+			err = db.CreateCollection(ctx, s.collection, opts)
+		}
+		if err != nil {
+			return fmt.Errorf("setting the validator of %s: %w", s.collection, err)
+		}
+	}
+
+	return nil
+}
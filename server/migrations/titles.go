@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var titleCollections = []string{"movies", "tv_shows"}
+
+// addTitleVersions starts the titles stored before versioning at version 1,
+// the version conditional writes filter on.
+func addTitleVersions(ctx context.Context, db *mongo.Database) error {
+	for _, collection := range titleCollections {
+		_, err := db.Collection(collection).UpdateMany(ctx,
+			bson.M{"version": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"version": 1}})
+		if err != nil {
+			return fmt.Errorf("versioning %s: %w", collection, err)
+		}
+	}
+
+	return nil
+}
+
+// convertTitleDates turns the release dates, first aired dates and air dates
+// stored as strings into BSON dates. Dates that do not parse are left as
+// they are and reported, so they can be fixed before the migration runs
+// again.
+func convertTitleDates(ctx context.Context, db *mongo.Database) error {
+	conversions := []struct {
+		collection string
+		filter     bson.M
+		set        bson.M
+	}{
+		{
+			collection: "movies",
+			filter:     bson.M{"release_date": bson.M{"$type": "string"}},
+			set:        bson.M{"release_date": dateExpr("$release_date")},
+		},
+		{
+			collection: "tv_shows",
+			filter: bson.M{"$or": bson.A{
+				bson.M{"first_aired": bson.M{"$type": "string"}},
+				bson.M{"seasons.episodes.air_date": bson.M{"$type": "string"}},
+			}},
+			set: bson.M{
+				"first_aired": dateExpr("$first_aired"),
+				"seasons": bson.M{"$map": bson.M{
+					"input": "$seasons",
+					"as":    "s",
+					"in": bson.M{"$mergeObjects": bson.A{"$$s", bson.M{"episodes": bson.M{"$map": bson.M{
+						"input": "$$s.episodes",
+						"as":    "e",
+						"in":    bson.M{"$mergeObjects": bson.A{"$$e", bson.M{"air_date": dateExpr("$$e.air_date")}}},
+					}}}}},
+				}},
+			},
+		},
+	}
+
+	for _, conversion := range conversions {
+		collection := db.Collection(conversion.collection)
+		_, err := collection.UpdateMany(ctx, conversion.filter, bson.A{bson.M{"$set": conversion.set}})
+		if err != nil {
+			return fmt.Errorf("converting the dates of %s: %w", conversion.collection, err)
+		}
+
+		left, err := collection.CountDocuments(ctx, conversion.filter)
+		if err != nil {
+			return fmt.Errorf("checking the dates of %s: %w", conversion.collection, err)
+		}
+		if left > 0 {
+			return fmt.Errorf("%d %s hold dates that are not ISO-8601 dates, fix them and run the migrations again",
+				left, conversion.collection)
+		}
+	}
+
+	return nil
+}
+
+// dateExpr converts the field to a date, at midnight UTC for a day alone,
+// when it holds a string that parses and leaves it as it is otherwise.
+func dateExpr(field string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": field}, "string"}},
+		bson.M{"$dateFromString": bson.M{"dateString": field, "timezone": "UTC", "onError": field}},
+		field,
+	}}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	AuditCreate        = "create"
+	AuditUpdate        = "update"
+	AuditPatch         = "patch"
+	AuditDelete        = "delete"
+	AuditRestore       = "restore"
+	AuditRollback      = "rollback"
+	AuditReview        = "review"
+	AuditAddSeason     = "add_season"
+	AuditUpdateSeason  = "update_season"
+	AuditDeleteSeason  = "delete_season"
+	AuditAddEpisode    = "add_episode"
+	AuditUpdateEpisode = "update_episode"
+	AuditDeleteEpisode = "delete_episode"
+	AuditImport        = "import"
+	AuditExport        = "export"
+	AuditRestoreAll    = "restore_catalog"
+	AuditLogin         = "login"
+	AuditLoginFailed   = "login_failed"
+	AuditRoleChange    = "role_change"
+)
+
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry records an administrative or security event: who did what to
+// which title or user, within which request, and whether it succeeded.
+// Entries are never modified once written.
+type AuditEntry struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ActorID      string        `bson:"actor_id" json:"actor_id"`
+	ActorRole    string        `bson:"actor_role" json:"actor_role"`
+	Action       string        `bson:"action" json:"action"`
+	ContentType  string        `bson:"content_type,omitempty" json:"content_type,omitempty"`
+	ImdbID       string        `bson:"imdb_id,omitempty" json:"imdb_id,omitempty"`
+	TargetUserID string        `bson:"target_user_id,omitempty" json:"target_user_id,omitempty"`
+	Email        string        `bson:"email,omitempty" json:"email,omitempty"`
+	Detail       string        `bson:"detail,omitempty" json:"detail,omitempty"`
+	RequestID    string        `bson:"request_id" json:"request_id"`
+	Outcome      string        `bson:"outcome" json:"outcome"`
+	Status       int           `bson:"status" json:"status"`
+	CreatedAt    time.Time     `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// CatalogArchiveVersion is the version of the archives the export writes.
+// It grows when their layout changes; a restore refuses newer archives.
+// Version 2 writes air dates and first aired dates as RFC 3339 timestamps,
+// where version 1 kept them as they were entered.
+const CatalogArchiveVersion = 2
+
+const (
+	RestoreMerge   = "merge"
+	RestoreReplace = "replace"
+)
+
+// CatalogArchive is a snapshot of the catalog: the titles, out of the trash,
+// with the genres and rankings they refer to.
+type CatalogArchive struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Movies     []Movie   `json:"movies"`
+	TVShows    []TVShow  `json:"tv_shows"`
+	Genres     []Genre   `json:"genres"`
+	Rankings   []Ranking `json:"rankings"`
+}
+
+// RestoreReport tells what a restore did with each title of the archive,
+// numbered by its position, and how many genres and rankings it restored.
+type RestoreReport struct {
+	Mode     string       `json:"mode"`
+	Movies   ImportReport `json:"movies"`
+	TVShows  ImportReport `json:"tv_shows"`
+	Genres   int          `json:"genres"`
+	Rankings int          `json:"rankings"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	ChartWindowDaily  = "daily"
+	ChartWindowWeekly = "weekly"
+
+	// ChartAllGenres is the genre of the chart ranking titles of every genre.
+	ChartAllGenres = "all"
+)
+
+// Chart is a precomputed trending chart for a window, content type and genre,
+// rebuilt periodically from the title events.
+type Chart struct {
+	ChartID     string       `bson:"chart_id" json:"chart_id"`
+	Window      string       `bson:"window" json:"window"`
+	ContentType string       `bson:"content_type" json:"content_type"`
+	Genre       string       `bson:"genre" json:"genre"`
+	Entries     []ChartEntry `bson:"entries" json:"entries"`
+	GeneratedAt time.Time    `bson:"generated_at" json:"generated_at"`
+}
+
+type ChartEntry struct {
+	Rank       int     `bson:"rank" json:"rank"`
+	ImdbID     string  `bson:"imdb_id" json:"imdb_id"`
+	Title      string  `bson:"title" json:"title"`
+	PosterPath string  `bson:"poster_path" json:"poster_path"`
+	Genre      []Genre `bson:"genre" json:"genre"`
+	Views      int     `bson:"views" json:"views"`
+	Watchlist  int     `bson:"watchlist" json:"watchlist"`
+	Ratings    int     `bson:"ratings" json:"ratings"`
+	Score      float64 `bson:"score" json:"score"`
+}
+
+func ChartID(window, contentType, genre string) string {
+	return window + ":" + contentType + ":" + genre
+}
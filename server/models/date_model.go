@@ -0,0 +1,65 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErrInvalidDate is returned by ParseDate for a value it cannot read.
+var ErrInvalidDate = errors.New("must be an ISO-8601 date, as 2024-01-31 or 2024-01-31T20:00:00Z")
+
+// Date is the release, first aired or air date of a title. It is stored as
+// a BSON date and written in JSON as an RFC 3339 timestamp, but reads any
+// ISO-8601 date ParseDate does, a day alone included.
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the date of the calendar day, at midnight UTC.
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	parsed, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+	d.Time = parsed
+	return nil
+}
+
+func (d Date) MarshalBSONValue() (byte, []byte, error) {
+	typ, data, err := bson.MarshalValue(d.Time)
+	return byte(typ), data, err
+}
+
+func (d *Date) UnmarshalBSONValue(typ byte, data []byte) error {
+	return bson.UnmarshalValue(bson.Type(typ), data, &d.Time)
+}
+
+// ParseDate reads an ISO-8601 date: a calendar day, taken at midnight UTC,
+// or an RFC 3339 timestamp, taken in UTC.
+func ParseDate(value string) (time.Time, error) {
+	if day, err := time.Parse(time.DateOnly, value); err == nil {
+		return day, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return parsed.UTC(), nil
+}
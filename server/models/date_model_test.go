@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-31T20:00:00Z", time.Date(2024, 1, 31, 20, 0, 0, 0, time.UTC)},
+		{"2024-01-31T20:00:00.5+02:00", time.Date(2024, 1, 31, 18, 0, 0, 500000000, time.UTC)},
+	}
+	for _, tt := range tests {
+		parsed, err := ParseDate(tt.value)
+		assert.NoError(t, err, tt.value)
+		assert.True(t, tt.want.Equal(parsed), tt.value)
+		assert.Equal(t, time.UTC, parsed.Location(), tt.value)
+	}
+
+	for _, value := range []string{"", "31/01/2024", "2024-02-30", "2024-01-31 20:00", "tomorrow"} {
+		_, err := ParseDate(value)
+		assert.ErrorIs(t, err, ErrInvalidDate, value)
+	}
+}
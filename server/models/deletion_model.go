@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Deletion marks a movie or TV show moved to the trash, hidden from every
+// read until it is restored or purged.
+type Deletion struct {
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy string     `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+}
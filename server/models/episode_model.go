@@ -4,6 +4,16 @@ type Episode struct {
 	EpisodeNumber int    `bson:"episode_number" json:"episode_number" validate:"required,min=1"`
 	EpisodeTitle  string `bson:"episode_title" json:"episode_title" validate:"required,min=1,max=200"`
 	Duration      int    `bson:"duration" json:"duration" validate:"required,min=1"`
-	AirDate       string `bson:"air_date" json:"air_date" validate:"required"`
+	AirDate       Date   `bson:"air_date" json:"air_date" validate:"required"`
 	Synopsis      string `bson:"synopsis" json:"synopsis" validate:"max=1000"`
 }
+
+// UpcomingEpisode is an episode yet to air along with the show and season it
+// belongs to.
+type UpcomingEpisode struct {
+	ImdbID       string `bson:"imdb_id" json:"imdb_id"`
+	Title        string `bson:"title" json:"title"`
+	PosterPath   string `bson:"poster_path" json:"poster_path"`
+	SeasonNumber int    `bson:"season_number" json:"season_number"`
+	Episode      `bson:",inline"`
+}
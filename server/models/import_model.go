@@ -0,0 +1,24 @@
+package models
+
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// ImportReport tells what a bulk import did with each row of its input.
+type ImportReport struct {
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+// ImportRow is the outcome of one row, numbered by the line it starts on.
+// Reason tells why a failed row was not imported.
+type ImportRow struct {
+	Row    int    `json:"row"`
+	ImdbID string `json:"imdb_id,omitempty"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
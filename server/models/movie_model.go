@@ -11,4 +11,7 @@ type Movie struct {
 	Genre       []Genre       `bson:"genre" json:"genre" validate:"required,dive"`
 	AdminReview string        `bson:"admin_review" json:"admin_review"`
 	Ranking     Ranking       `bson:"ranking" json:"ranking" validate:"required"`
+	ReleaseDate Date          `bson:"release_date,omitempty" json:"release_date,omitzero"`
+	Version     int64         `bson:"version" json:"version"`
+	Deletion    `bson:",inline"`
 }
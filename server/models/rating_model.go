@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Rating is a user's personal score for a movie or TV show, from 1 to 5.
+type Rating struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID      string        `bson:"user_id" json:"user_id"`
+	ImdbID      string        `bson:"imdb_id" json:"imdb_id"`
+	ContentType string        `bson:"content_type" json:"content_type" validate:"required,oneof=movie tv_show"`
+	Score       int           `bson:"score" json:"score" validate:"required,min=1,max=5"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// OnboardingRatings holds the titles a new user rated from the onboarding
+// sample, used to seed their favourite genres.
+type OnboardingRatings struct {
+	Ratings []OnboardingRating `json:"ratings" validate:"required,min=1,dive"`
+}
+
+type OnboardingRating struct {
+	ImdbID      string `json:"imdb_id" validate:"required"`
+	ContentType string `json:"content_type" validate:"required,oneof=movie tv_show"`
+	Score       int    `json:"score" validate:"required,min=1,max=5"`
+}
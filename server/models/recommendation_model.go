@@ -0,0 +1,23 @@
+package models
+
+const (
+	ContentTypeMovie  = "movie"
+	ContentTypeTVShow = "tv_show"
+)
+
+// RecommendationItem is a movie or TV show summary in the mixed recommendation
+// feed, ContentType tells which one it is.
+type RecommendationItem struct {
+	ContentType string  `bson:"content_type" json:"content_type"`
+	ImdbID      string  `bson:"imdb_id" json:"imdb_id"`
+	Title       string  `bson:"title" json:"title"`
+	PosterPath  string  `bson:"poster_path" json:"poster_path"`
+	Genre       []Genre `bson:"genre" json:"genre"`
+	Ranking     Ranking `bson:"ranking" json:"ranking"`
+	Score       float64 `bson:"score" json:"score,omitempty"`
+}
+
+type RecommendationFeed struct {
+	Items      []RecommendationItem `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionReview   = "review"
+	RevisionDelete   = "delete"
+	RevisionRestore  = "restore"
+	RevisionRollback = "rollback"
+)
+
+// Revision records one write to a movie or TV show: who made it, what it
+// changed and the document it left, which a rollback restores. The revision
+// of a delete has no document.
+type Revision struct {
+	ID          bson.ObjectID  `bson:"_id,omitempty" json:"_id,omitempty"`
+	ImdbID      string         `bson:"imdb_id" json:"imdb_id"`
+	ContentType string         `bson:"content_type" json:"content_type"`
+	Action      string         `bson:"action" json:"action"`
+	Version     int64          `bson:"version" json:"version"`
+	UserID      string         `bson:"user_id" json:"user_id"`
+	CreatedAt   time.Time      `bson:"created_at" json:"created_at"`
+	Changes     []FieldChange  `bson:"changes,omitempty" json:"changes,omitempty"`
+	Document    map[string]any `bson:"document,omitempty" json:"document,omitempty"`
+}
+
+// FieldChange is a field whose value differs between two versions of a
+// document, named by its dotted path. A missing value was added or removed.
+type FieldChange struct {
+	Field string `bson:"field" json:"field"`
+	Old   any    `bson:"old,omitempty" json:"old,omitempty"`
+	New   any    `bson:"new,omitempty" json:"new,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const (
+	TitleEventView      = "view"
+	TitleEventWatchlist = "watchlist"
+	TitleEventRating    = "rating"
+)
+
+// TitleEvent is a single user interaction with a title, the raw input of the
+// trending charts.
+type TitleEvent struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	ImdbID      string        `bson:"imdb_id" json:"imdb_id"`
+	ContentType string        `bson:"content_type" json:"content_type"`
+	EventType   string        `bson:"event_type" json:"event_type"`
+	UserID      string        `bson:"user_id" json:"user_id"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+}
@@ -2,6 +2,12 @@ package models
 
 import "go.mongodb.org/mongo-driver/v2/bson"
 
+const (
+	TVShowOngoing   = "Ongoing"
+	TVShowFinished  = "Finished"
+	TVShowCancelled = "Cancelled"
+)
+
 type TVShow struct {
 	ID           bson.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
 	ImdbID       string        `bson:"imdb_id" json:"imdb_id" validate:"required"`
@@ -14,5 +20,7 @@ type TVShow struct {
 	Seasons      []Season      `bson:"seasons" json:"seasons" validate:"required,dive"`
 	TotalSeasons int           `bson:"total_seasons" json:"total_seasons" validate:"required,min=1"`
 	Status       string        `bson:"status" json:"status" validate:"required,oneof=Ongoing Finished Cancelled"`
-	FirstAired   string        `bson:"first_aired" json:"first_aired" validate:"required"`
+	FirstAired   Date          `bson:"first_aired" json:"first_aired" validate:"required"`
+	Version      int64         `bson:"version" json:"version"`
+	Deletion     `bson:",inline"`
 }
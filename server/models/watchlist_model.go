@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type WatchlistItem struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	UserID      string        `bson:"user_id" json:"user_id"`
+	ImdbID      string        `bson:"imdb_id" json:"imdb_id" validate:"required"`
+	ContentType string        `bson:"content_type" json:"content_type" validate:"required,oneof=movie tv_show"`
+	AddedAt     time.Time     `bson:"added_at" json:"added_at"`
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
+)
+
+// HealthChecker reports whether the database behind the repositories can
+// serve requests.
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+	// MissingIndexes returns the "collection.index" names of the entries of
+	// Indexes that do not exist.
+	MissingIndexes(ctx context.Context) ([]string, error)
+}
+
+type mongoHealthChecker struct {
+	db *mongo.Database
+}
+
+func NewMongoHealthChecker(db *mongo.Database) HealthChecker {
+	return &mongoHealthChecker{db}
+}
+
+func (h *mongoHealthChecker) Ping(ctx context.Context) error {
+	return h.db.Client().Ping(ctx, readpref.Primary())
+}
+
+func (h *mongoHealthChecker) MissingIndexes(ctx context.Context) ([]string, error) {
+	existing := map[string][]string{}
+	missing := []string{}
+	for _, index := range Indexes {
+		names, listed := existing[index.Collection]
+		if !listed {
+			specs, err := h.db.Collection(index.Collection).Indexes().ListSpecifications(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, spec := range specs {
+				names = append(names, spec.Name)
+			}
+			existing[index.Collection] = names
+		}
+
+		if !slices.Contains(names, index.Name) {
+			missing = append(missing, index.Collection+"."+index.Name)
+		}
+	}
+
+	return missing, nil
+}
+
+// memoryHealthChecker is always healthy, the in-memory repositories need
+// neither a connection nor indexes.
+type memoryHealthChecker struct{}
+
+func NewMemoryHealthChecker() HealthChecker {
+	return memoryHealthChecker{}
+}
+
+func (memoryHealthChecker) Ping(_ context.Context) error {
+	return nil
+}
+
+func (memoryHealthChecker) MissingIndexes(_ context.Context) ([]string, error) {
+	return []string{}, nil
+}
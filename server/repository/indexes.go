@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// IndexSpec describes an index the queries of a collection rely on.
+type IndexSpec struct {
+	Collection string
+	Name       string
+	Keys       bson.D
+	Unique     bool
+}
+
+// Indexes lists the indexes every deployment is expected to have, which the
+// migrations create and the readiness check looks for. An index added here
+// needs a migration creating it.
+var Indexes = []IndexSpec{
+	{Collection: "movies", Name: "imdb_id_1", Keys: bson.D{{Key: "imdb_id", Value: 1}}, Unique: true},
+	{Collection: "movies", Name: "genre.genre_name_1", Keys: bson.D{{Key: "genre.genre_name", Value: 1}}},
+	{Collection: "movies", Name: "ranking.ranking_value_1", Keys: bson.D{{Key: "ranking.ranking_value", Value: 1}}},
+	{Collection: "tv_shows", Name: "imdb_id_1", Keys: bson.D{{Key: "imdb_id", Value: 1}}, Unique: true},
+	{Collection: "tv_shows", Name: "genre.genre_name_1", Keys: bson.D{{Key: "genre.genre_name", Value: 1}}},
+	{Collection: "tv_shows", Name: "ranking.ranking_value_1", Keys: bson.D{{Key: "ranking.ranking_value", Value: 1}}},
+	{Collection: "users", Name: "email_1", Keys: bson.D{{Key: "email", Value: 1}}, Unique: true},
+	{Collection: "users", Name: "user_id_1", Keys: bson.D{{Key: "user_id", Value: 1}}, Unique: true},
+	{Collection: "genres", Name: "genre_id_1", Keys: bson.D{{Key: "genre_id", Value: 1}}},
+	{Collection: "rankings", Name: "ranking_value_1", Keys: bson.D{{Key: "ranking_value", Value: 1}}},
+	{Collection: "ratings", Name: "user_id_1_imdb_id_1", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}}},
+	{Collection: "watchlist", Name: "user_id_1_imdb_id_1", Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "imdb_id", Value: 1}}},
+	{Collection: "title_events", Name: "created_at_1", Keys: bson.D{{Key: "created_at", Value: 1}}},
+	{Collection: "charts", Name: "chart_id_1", Keys: bson.D{{Key: "chart_id", Value: 1}}},
+	{Collection: "revisions", Name: "content_type_1_imdb_id_1_created_at_-1", Keys: bson.D{
+		{Key: "content_type", Value: 1}, {Key: "imdb_id", Value: 1}, {Key: "created_at", Value: -1},
+	}},
+	{Collection: "audit_log", Name: "created_at_-1", Keys: bson.D{{Key: "created_at", Value: -1}}},
+	{Collection: "audit_log", Name: "actor_id_1_created_at_-1", Keys: bson.D{
+		{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1},
+	}},
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type memoryRatingRepository struct {
+	mu      sync.RWMutex
+	ratings []models.Rating
+}
+
+type memoryWatchlistRepository struct {
+	mu    sync.RWMutex
+	items []models.WatchlistItem
+}
+
+type memoryTitleEventRepository struct {
+	mu     sync.RWMutex
+	events []models.TitleEvent
+}
+
+type memoryChartRepository struct {
+	mu     sync.RWMutex
+	charts map[string]models.Chart
+}
+
+func NewMemoryRatingRepository() RatingRepository {
+	return &memoryRatingRepository{}
+}
+
+func NewMemoryWatchlistRepository() WatchlistRepository {
+	return &memoryWatchlistRepository{}
+}
+
+func NewMemoryTitleEventRepository() TitleEventRepository {
+	return &memoryTitleEventRepository{}
+}
+
+func NewMemoryChartRepository() ChartRepository {
+	return &memoryChartRepository{charts: map[string]models.Chart{}}
+}
+
+// Ratings
+// ---------------------------------------------------------------------------------------
+
+func (r *memoryRatingRepository) FindByUser(_ context.Context, userId string) ([]models.Rating, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ratings := []models.Rating{}
+	for _, rating := range r.ratings {
+		if rating.UserID == userId {
+			ratings = append(ratings, rating)
+		}
+	}
+	sort.SliceStable(ratings, func(i, j int) bool { return ratings[i].UpdatedAt.After(ratings[j].UpdatedAt) })
+
+	return ratings, nil
+}
+
+func (r *memoryRatingRepository) FindLiked(_ context.Context, imdbID string, userIds []string,
+	minScore int) ([]models.Rating, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ratings := []models.Rating{}
+	for _, rating := range r.ratings {
+		if rating.Score < minScore ||
+			(imdbID != "" && rating.ImdbID != imdbID) ||
+			(userIds != nil && !slices.Contains(userIds, rating.UserID)) {
+			continue
+		}
+		ratings = append(ratings, rating)
+	}
+
+	return ratings, nil
+}
+
+func (r *memoryRatingRepository) Upsert(_ context.Context, rating models.Rating) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, existing := range r.ratings {
+		if existing.UserID == rating.UserID && existing.ImdbID == rating.ImdbID {
+			r.ratings[i].ContentType = rating.ContentType
+			r.ratings[i].Score = rating.Score
+			r.ratings[i].UpdatedAt = now
+			return nil
+		}
+	}
+
+	rating.ID = bson.NewObjectID()
+	rating.CreatedAt = now
+	rating.UpdatedAt = now
+	r.ratings = append(r.ratings, rating)
+
+	return nil
+}
+
+func (r *memoryRatingRepository) DeleteByTitles(_ context.Context, contentType string, imdbIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ratings = slices.DeleteFunc(r.ratings, func(rating models.Rating) bool {
+		return rating.ContentType == contentType && slices.Contains(imdbIDs, rating.ImdbID)
+	})
+
+	return nil
+}
+
+// Watchlist
+// ---------------------------------------------------------------------------------------
+
+func (r *memoryWatchlistRepository) FindByUser(_ context.Context, userId string) ([]models.WatchlistItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []models.WatchlistItem{}
+	for _, item := range r.items {
+		if item.UserID == userId {
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].AddedAt.After(items[j].AddedAt) })
+
+	return items, nil
+}
+
+func (r *memoryWatchlistRepository) FindSaved(_ context.Context, imdbID string,
+	userIds []string) ([]models.WatchlistItem, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []models.WatchlistItem{}
+	for _, item := range r.items {
+		if (imdbID != "" && item.ImdbID != imdbID) ||
+			(userIds != nil && !slices.Contains(userIds, item.UserID)) {
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *memoryWatchlistRepository) Add(_ context.Context, item models.WatchlistItem) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.items {
+		if existing.UserID == item.UserID && existing.ImdbID == item.ImdbID {
+			return false, nil
+		}
+	}
+
+	item.ID = bson.NewObjectID()
+	item.AddedAt = time.Now()
+	r.items = append(r.items, item)
+
+	return true, nil
+}
+
+func (r *memoryWatchlistRepository) Remove(_ context.Context, userId, imdbID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, item := range r.items {
+		if item.UserID == userId && item.ImdbID == imdbID {
+			r.items = slices.Delete(r.items, i, i+1)
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (r *memoryWatchlistRepository) DeleteByTitles(_ context.Context, contentType string, imdbIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items = slices.DeleteFunc(r.items, func(item models.WatchlistItem) bool {
+		return item.ContentType == contentType && slices.Contains(imdbIDs, item.ImdbID)
+	})
+
+	return nil
+}
+
+// Title events
+// ---------------------------------------------------------------------------------------
+
+func (r *memoryTitleEventRepository) Insert(_ context.Context, event models.TitleEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	event.ID = bson.NewObjectID()
+	r.events = append(r.events, event)
+
+	return nil
+}
+
+func (r *memoryTitleEventRepository) CountSince(_ context.Context, contentType string,
+	since time.Time) ([]TitleEventCount, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byTitle := map[string]*TitleEventCount{}
+	for _, event := range r.events {
+		if event.ContentType != contentType || event.CreatedAt.Before(since) {
+			continue
+		}
+
+		count, ok := byTitle[event.ImdbID]
+		if !ok {
+			count = &TitleEventCount{ImdbID: event.ImdbID}
+			byTitle[event.ImdbID] = count
+		}
+		switch event.EventType {
+		case models.TitleEventView:
+			count.Views++
+		case models.TitleEventWatchlist:
+			count.Watchlist++
+		case models.TitleEventRating:
+			count.Ratings++
+		}
+	}
+
+	counts := make([]TitleEventCount, 0, len(byTitle))
+	for _, count := range byTitle {
+		counts = append(counts, *count)
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].ImdbID < counts[j].ImdbID })
+
+	return counts, nil
+}
+
+func (r *memoryTitleEventRepository) DeleteBefore(_ context.Context, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = slices.DeleteFunc(r.events, func(event models.TitleEvent) bool {
+		return event.CreatedAt.Before(before)
+	})
+
+	return nil
+}
+
+// Charts
+// ---------------------------------------------------------------------------------------
+
+func (r *memoryChartRepository) FindByID(_ context.Context, chartID string) (models.Chart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chart, ok := r.charts[chartID]
+	if !ok {
+		return chart, ErrNotFound
+	}
+	return chart, nil
+}
+
+func (r *memoryChartRepository) ReplaceAll(_ context.Context, window, contentType string,
+	charts []models.Chart) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, chart := range r.charts {
+		if chart.Window == window && chart.ContentType == contentType {
+			delete(r.charts, id)
+		}
+	}
+	for _, chart := range charts {
+		r.charts[chart.ChartID] = chart
+	}
+
+	return nil
+}
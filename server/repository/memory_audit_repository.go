@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type memoryAuditRepository struct {
+	mu      sync.RWMutex
+	entries []models.AuditEntry
+}
+
+func NewMemoryAuditRepository() AuditRepository {
+	return &memoryAuditRepository{}
+}
+
+func (r *memoryAuditRepository) Insert(_ context.Context, entry models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID.IsZero() {
+		entry.ID = bson.NewObjectID()
+	}
+	r.entries = append(r.entries, entry)
+
+	return nil
+}
+
+func (r *memoryAuditRepository) Find(_ context.Context, query AuditQuery) ([]models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Entries are appended as they are made, so newest first is reverse order
+	entries := []models.AuditEntry{}
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		entry := r.entries[i]
+		if query.ActorID != "" && entry.ActorID != query.ActorID ||
+			query.Action != "" && entry.Action != query.Action ||
+			!query.From.IsZero() && entry.CreatedAt.Before(query.From) ||
+			!query.To.IsZero() && !entry.CreatedAt.Before(query.To) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return page(entries, query.Skip, query.Limit), nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"server/models"
+)
+
+type memoryGenreRepository struct {
+	mu     sync.RWMutex
+	genres []models.Genre
+}
+
+type memoryRankingRepository struct {
+	mu       sync.RWMutex
+	rankings []models.Ranking
+}
+
+// NewMemoryGenreRepository returns a repository holding the given genres.
+func NewMemoryGenreRepository(genres ...models.Genre) GenreRepository {
+	return &memoryGenreRepository{genres: genres}
+}
+
+// NewMemoryRankingRepository returns a repository holding the given rankings.
+func NewMemoryRankingRepository(rankings ...models.Ranking) RankingRepository {
+	return &memoryRankingRepository{rankings: rankings}
+}
+
+func (r *memoryGenreRepository) FindAll(_ context.Context) ([]models.Genre, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.Genre{}, r.genres...), nil
+}
+
+func (r *memoryRankingRepository) FindAll(_ context.Context) ([]models.Ranking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.Ranking{}, r.rankings...), nil
+}
+
+func (r *memoryGenreRepository) Upsert(_ context.Context, genre models.Genre) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.genres, func(g models.Genre) bool { return g.GenreID == genre.GenreID })
+	if i < 0 {
+		r.genres = append(r.genres, genre)
+	} else {
+		r.genres[i] = genre
+	}
+	return nil
+}
+
+func (r *memoryGenreRepository) ReplaceAll(_ context.Context, genres []models.Genre) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.genres = slices.Clone(genres)
+	return nil
+}
+
+func (r *memoryRankingRepository) Upsert(_ context.Context, ranking models.Ranking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.rankings, func(k models.Ranking) bool { return k.RankingValue == ranking.RankingValue })
+	if i < 0 {
+		r.rankings = append(r.rankings, ranking)
+	} else {
+		r.rankings[i] = ranking
+	}
+	return nil
+}
+
+func (r *memoryRankingRepository) ReplaceAll(_ context.Context, rankings []models.Ranking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rankings = slices.Clone(rankings)
+	return nil
+}
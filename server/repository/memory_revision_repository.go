@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type memoryRevisionRepository struct {
+	mu        sync.RWMutex
+	revisions []models.Revision
+}
+
+func NewMemoryRevisionRepository() RevisionRepository {
+	return &memoryRevisionRepository{}
+}
+
+func (r *memoryRevisionRepository) Insert(_ context.Context, revision models.Revision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if revision.ID.IsZero() {
+		revision.ID = bson.NewObjectID()
+	}
+	r.revisions = append(r.revisions, revision)
+
+	return nil
+}
+
+func (r *memoryRevisionRepository) FindByTitle(_ context.Context, contentType,
+	imdbID string) ([]models.Revision, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Revisions are appended as they are made, so newest first is reverse order
+	revisions := []models.Revision{}
+	for i := len(r.revisions) - 1; i >= 0; i-- {
+		revision := r.revisions[i]
+		if revision.ContentType == contentType && revision.ImdbID == imdbID {
+			revision.Changes, revision.Document = nil, nil
+			revisions = append(revisions, revision)
+		}
+	}
+
+	return revisions, nil
+}
+
+func (r *memoryRevisionRepository) FindByID(_ context.Context, id bson.ObjectID) (models.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, revision := range r.revisions {
+		if revision.ID == id {
+			return revision, nil
+		}
+	}
+
+	return models.Revision{}, ErrNotFound
+}
@@ -0,0 +1,673 @@
+package repository
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// memoryTitleRepository implements TitleRepository over a slice, mirroring
+// the queries of mongoTitleRepository.
+type memoryTitleRepository[T any] struct {
+	mu          sync.RWMutex
+	titles      []T
+	contentType string
+	fields      titleAccessor[T]
+}
+
+type memoryMovieRepository struct {
+	*memoryTitleRepository[models.Movie]
+}
+
+type memoryTVShowRepository struct {
+	*memoryTitleRepository[models.TVShow]
+}
+
+func NewMemoryMovieRepository() MovieRepository {
+	return &memoryMovieRepository{&memoryTitleRepository[models.Movie]{
+		contentType: models.ContentTypeMovie,
+		fields:      movieFields,
+	}}
+}
+
+func NewMemoryTVShowRepository() TVShowRepository {
+	return &memoryTVShowRepository{&memoryTitleRepository[models.TVShow]{
+		contentType: models.ContentTypeTVShow,
+		fields:      tvShowFields,
+	}}
+}
+
+func (r *memoryTVShowRepository) FindSeason(_ context.Context, imdbID string,
+	seasonNumber int) (models.Season, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := r.indexOf(imdbID)
+	if i < 0 {
+		return models.Season{}, ErrNotFound
+	}
+	for _, season := range r.titles[i].Seasons {
+		if season.SeasonNumber == seasonNumber {
+			return season, nil
+		}
+	}
+	return models.Season{}, ErrNotFound
+}
+
+func (r *memoryTVShowRepository) FindEpisode(ctx context.Context, imdbID string, seasonNumber,
+	episodeNumber int) (models.Episode, error) {
+
+	season, err := r.FindSeason(ctx, imdbID, seasonNumber)
+	if err != nil {
+		return models.Episode{}, err
+	}
+	if j := indexOfEpisode(season, episodeNumber); j >= 0 {
+		return season.Episodes[j], nil
+	}
+	return models.Episode{}, ErrNotFound
+}
+
+func (r *memoryTVShowRepository) UpcomingEpisodes(_ context.Context,
+	query UpcomingQuery) ([]models.UpcomingEpisode, error) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	episodes := []models.UpcomingEpisode{}
+	for _, show := range r.live() {
+		if show.Status != models.TVShowOngoing {
+			continue
+		}
+		for _, season := range show.Seasons {
+			for _, episode := range season.Episodes {
+				if within(episode.AirDate.Time, query.Between) {
+					episodes = append(episodes, models.UpcomingEpisode{
+						ImdbID:       show.ImdbID,
+						Title:        show.Title,
+						PosterPath:   show.PosterPath,
+						SeasonNumber: season.SeasonNumber,
+						Episode:      episode,
+					})
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(episodes, func(i, j int) bool {
+		a, b := episodes[i], episodes[j]
+		if !a.AirDate.Equal(b.AirDate.Time) {
+			return a.AirDate.Before(b.AirDate.Time)
+		}
+		if a.ImdbID != b.ImdbID {
+			return a.ImdbID < b.ImdbID
+		}
+		if a.SeasonNumber != b.SeasonNumber {
+			return a.SeasonNumber < b.SeasonNumber
+		}
+		return a.EpisodeNumber < b.EpisodeNumber
+	})
+
+	return page(episodes, query.Skip, query.Limit), nil
+}
+
+func (r *memoryTVShowRepository) AddSeason(_ context.Context, imdbID string, season models.Season,
+	version int64) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(imdbID)
+	if i >= 0 && slices.ContainsFunc(r.titles[i].Seasons, func(s models.Season) bool {
+		return s.SeasonNumber == season.SeasonNumber
+	}) {
+		return ErrDuplicate
+	}
+	i, err := r.indexOfVersion(imdbID, version)
+	if err != nil {
+		return err
+	}
+
+	show := &r.titles[i]
+	show.Seasons = append(slices.Clone(show.Seasons), season)
+	show.TotalSeasons++
+	show.Version++
+
+	return nil
+}
+
+func (r *memoryTVShowRepository) UpdateSeason(_ context.Context, imdbID string, season models.Season,
+	version int64) error {
+
+	return r.updateSeason(imdbID, season.SeasonNumber, version, func(show *models.TVShow, i int) bool {
+		show.Seasons[i].Episodes = slices.Clone(season.Episodes)
+		return true
+	})
+}
+
+func (r *memoryTVShowRepository) DeleteSeason(_ context.Context, imdbID string, seasonNumber int,
+	version int64) error {
+
+	return r.updateSeason(imdbID, seasonNumber, version, func(show *models.TVShow, i int) bool {
+		show.Seasons = slices.Delete(show.Seasons, i, i+1)
+		show.TotalSeasons--
+		return true
+	})
+}
+
+func (r *memoryTVShowRepository) AddEpisode(_ context.Context, imdbID string, seasonNumber int,
+	episode models.Episode, version int64) error {
+
+	return r.updateSeason(imdbID, seasonNumber, version, func(show *models.TVShow, i int) bool {
+		show.Seasons[i].Episodes = append(show.Seasons[i].Episodes, episode)
+		return true
+	})
+}
+
+func (r *memoryTVShowRepository) UpdateEpisode(_ context.Context, imdbID string, seasonNumber int,
+	episode models.Episode, version int64) error {
+
+	return r.updateSeason(imdbID, seasonNumber, version, func(show *models.TVShow, i int) bool {
+		j := indexOfEpisode(show.Seasons[i], episode.EpisodeNumber)
+		if j < 0 {
+			return false
+		}
+		show.Seasons[i].Episodes[j] = episode
+		return true
+	})
+}
+
+func (r *memoryTVShowRepository) DeleteEpisode(_ context.Context, imdbID string, seasonNumber, episodeNumber int,
+	version int64) error {
+
+	return r.updateSeason(imdbID, seasonNumber, version, func(show *models.TVShow, i int) bool {
+		season := &show.Seasons[i]
+		j := indexOfEpisode(*season, episodeNumber)
+		if j < 0 {
+			return false
+		}
+		season.Episodes = slices.Delete(season.Episodes, j, j+1)
+		for k := range season.Episodes {
+			if season.Episodes[k].EpisodeNumber > episodeNumber {
+				season.Episodes[k].EpisodeNumber--
+			}
+		}
+		return true
+	})
+}
+
+// updateSeason applies the write to a copy of the seasons of the TV show,
+// so that a write finding nothing to change leaves the show untouched.
+func (r *memoryTVShowRepository) updateSeason(imdbID string, seasonNumber int, version int64,
+	apply func(show *models.TVShow, i int) bool) error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.indexOfVersion(imdbID, version)
+	if err != nil {
+		return err
+	}
+
+	show := r.titles[i]
+	show.Seasons = slices.Clone(show.Seasons)
+	season := slices.IndexFunc(show.Seasons, func(s models.Season) bool { return s.SeasonNumber == seasonNumber })
+	if season < 0 {
+		return ErrNotFound
+	}
+	show.Seasons[season].Episodes = slices.Clone(show.Seasons[season].Episodes)
+	if !apply(&show, season) {
+		return ErrNotFound
+	}
+	show.Version++
+	r.titles[i] = show
+
+	return nil
+}
+
+func indexOfEpisode(season models.Season, episodeNumber int) int {
+	return slices.IndexFunc(season.Episodes, func(e models.Episode) bool { return e.EpisodeNumber == episodeNumber })
+}
+
+func (r *memoryTitleRepository[T]) ContentType() string {
+	return r.contentType
+}
+
+func (r *memoryTitleRepository[T]) FindAll(_ context.Context) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	titles := []T{}
+	for _, title := range r.live() {
+		titles = append(titles, *title)
+	}
+
+	return titles, nil
+}
+
+func (r *memoryTitleRepository[T]) FindBetween(_ context.Context, between DateRange) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	open := between.From.IsZero() && between.To.IsZero()
+	titles := []T{}
+	for _, title := range r.live() {
+		if open || within(r.fields.date(title), between) {
+			titles = append(titles, *title)
+		}
+	}
+
+	return titles, nil
+}
+
+func (r *memoryTitleRepository[T]) FindByImdbID(_ context.Context, imdbID string) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(imdbID); i >= 0 {
+		return r.titles[i], nil
+	}
+
+	var zero T
+	return zero, ErrNotFound
+}
+
+func (r *memoryTitleRepository[T]) FindByImdbIDs(_ context.Context, imdbIDs []string) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	titles := []T{}
+	for _, imdbID := range imdbIDs {
+		if i := r.indexOf(imdbID); i >= 0 {
+			titles = append(titles, r.titles[i])
+		}
+	}
+
+	return titles, nil
+}
+
+func (r *memoryTitleRepository[T]) Exists(_ context.Context, imdbID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.indexOf(imdbID) >= 0, nil
+}
+
+func (r *memoryTitleRepository[T]) ExistsWithTitle(_ context.Context, title string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, candidate := range r.live() {
+		if r.fields.title(candidate) == title {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *memoryTitleRepository[T]) Insert(_ context.Context, title T) (bson.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if i := r.indexOfStored(r.fields.imdbID(&title)); i >= 0 {
+		return bson.NilObjectID, r.duplicateOf(i)
+	}
+
+	id := r.fields.id(&title)
+	if id.IsZero() {
+		*id = bson.NewObjectID()
+	} else if slices.ContainsFunc(r.titles, func(stored T) bool { return *r.fields.id(&stored) == *id }) {
+		return bson.NilObjectID, ErrIDTaken
+	}
+	*r.fields.version(&title) = 1
+	*r.fields.deletion(&title) = models.Deletion{}
+	r.titles = append(r.titles, title)
+
+	return *id, nil
+}
+
+func (r *memoryTitleRepository[T]) Replace(_ context.Context, imdbID string, title T,
+	version int64) (int64, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.indexOfVersion(imdbID, version)
+	if err != nil {
+		return 0, err
+	}
+	if other := r.indexOfStored(r.fields.imdbID(&title)); other >= 0 && other != i {
+		return 0, r.duplicateOf(other)
+	}
+
+	// Like a Mongo replace, the document keeps its _id
+	*r.fields.id(&title) = *r.fields.id(&r.titles[i])
+	*r.fields.version(&title) = *r.fields.version(&r.titles[i]) + 1
+	*r.fields.deletion(&title) = models.Deletion{}
+	r.titles[i] = title
+
+	return 1, nil
+}
+
+func (r *memoryTitleRepository[T]) Delete(_ context.Context, imdbID, deletedBy string, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.indexOfVersion(imdbID, version)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	*r.fields.deletion(&r.titles[i]) = models.Deletion{DeletedAt: &now, DeletedBy: deletedBy}
+	*r.fields.version(&r.titles[i])++
+
+	return nil
+}
+
+func (r *memoryTitleRepository[T]) UpdateReview(_ context.Context, imdbID, adminReview string,
+	ranking models.Ranking, version int64) error {
+
+	return r.update(imdbID, version, func(title *T) {
+		r.fields.setReview(title, adminReview, ranking)
+	})
+}
+
+func (r *memoryTitleRepository[T]) FindDeleted(_ context.Context) ([]T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	titles := []T{}
+	for i := range r.titles {
+		if r.deleted(&r.titles[i]) {
+			titles = append(titles, r.titles[i])
+		}
+	}
+	sort.SliceStable(titles, func(i, j int) bool {
+		return r.fields.deletion(&titles[i]).DeletedAt.After(*r.fields.deletion(&titles[j]).DeletedAt)
+	})
+
+	return titles, nil
+}
+
+func (r *memoryTitleRepository[T]) DeletedBefore(_ context.Context, before time.Time) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	imdbIDs := []string{}
+	for i := range r.titles {
+		if r.deleted(&r.titles[i]) && r.fields.deletion(&r.titles[i]).DeletedAt.Before(before) {
+			imdbIDs = append(imdbIDs, r.fields.imdbID(&r.titles[i]))
+		}
+	}
+
+	return imdbIDs, nil
+}
+
+func (r *memoryTitleRepository[T]) Restore(_ context.Context, imdbID string) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOfStored(imdbID)
+	if i < 0 || !r.deleted(&r.titles[i]) {
+		var zero T
+		return zero, ErrNotFound
+	}
+	trashed := r.titles[i]
+	*r.fields.deletion(&r.titles[i]) = models.Deletion{}
+	*r.fields.version(&r.titles[i])++
+
+	return trashed, nil
+}
+
+func (r *memoryTitleRepository[T]) Purge(_ context.Context, imdbIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.titles = slices.DeleteFunc(r.titles, func(title T) bool {
+		return r.deleted(&title) && slices.Contains(imdbIDs, r.fields.imdbID(&title))
+	})
+
+	return nil
+}
+
+func (r *memoryTitleRepository[T]) ReplaceAll(_ context.Context, titles []T) error {
+	replaced := make([]T, 0, len(titles))
+	imdbIDs, ids := map[string]bool{}, map[bson.ObjectID]bool{}
+	for _, title := range titles {
+		imdbID, id := r.fields.imdbID(&title), r.fields.id(&title)
+		if id.IsZero() {
+			*id = bson.NewObjectID()
+		}
+		if imdbIDs[imdbID] || ids[*id] {
+			return ErrDuplicate
+		}
+		imdbIDs[imdbID], ids[*id] = true, true
+
+		*r.fields.version(&title) = 1
+		*r.fields.deletion(&title) = models.Deletion{}
+		replaced = append(replaced, title)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.titles = replaced
+	return nil
+}
+
+func (r *memoryTitleRepository[T]) Summaries(_ context.Context, imdbIDs []string) ([]models.RecommendationItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []models.RecommendationItem{}
+	for _, title := range r.live() {
+		if slices.Contains(imdbIDs, r.fields.imdbID(title)) {
+			items = append(items, r.summary(title))
+		}
+	}
+
+	return items, nil
+}
+
+func (r *memoryTitleRepository[T]) Recommend(_ context.Context, query RecommendationQuery) ([]models.RecommendationItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	weights := query.Weights
+
+	items := []models.RecommendationItem{}
+	for _, title := range r.live() {
+		item := r.summary(title)
+		recency := recencyScore(*r.fields.id(title), now)
+
+		if len(query.FavouriteGenres) > 0 {
+			matched := countShared(item.Genre, query.FavouriteGenres)
+			if matched == 0 {
+				continue
+			}
+			item.Score = weights.Genre*float64(matched) +
+				weights.Ranking*rankingScore(item.Ranking.RankingValue) +
+				weights.Recency*recency
+		} else {
+			item.Score = weights.Ranking*rankingScore(item.Ranking.RankingValue) +
+				weights.Trending*query.Trending[item.ImdbID] +
+				weights.Recency*recency
+		}
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		if items[i].Ranking.RankingValue != items[j].Ranking.RankingValue {
+			return items[i].Ranking.RankingValue < items[j].Ranking.RankingValue
+		}
+		return items[i].ImdbID < items[j].ImdbID
+	})
+
+	return page(items, query.Skip, query.Limit), nil
+}
+
+func (r *memoryTitleRepository[T]) Similar(_ context.Context, query SimilarityQuery) ([]models.RecommendationItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	source := query.Source
+	sourceGenres := genreNames(source.Genre)
+
+	items := []models.RecommendationItem{}
+	for _, title := range r.live() {
+		item := r.summary(title)
+		coActivity, shareAudience := query.CoActivity[item.ImdbID]
+		if item.ImdbID == source.ImdbID || (countShared(item.Genre, sourceGenres) == 0 && !shareAudience) {
+			continue
+		}
+
+		item.Score = similarGenreWeight*genreJaccard(item.Genre, sourceGenres) +
+			similarRankingWeight*rankingProximity(source.Ranking.RankingValue, item.Ranking.RankingValue) +
+			similarCoActivityWeight*coActivity
+		items = append(items, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].ImdbID < items[j].ImdbID
+	})
+
+	return page(items, 0, query.Limit), nil
+}
+
+func (r *memoryTitleRepository[T]) Sample(_ context.Context, limit int64) ([]models.RecommendationItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	candidates := []models.RecommendationItem{}
+	for _, title := range r.live() {
+		candidates = append(candidates, r.summary(title))
+	}
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	candidates = page(candidates, 0, sampleCandidates)
+
+	sample := []models.RecommendationItem{}
+	coveredGenres := map[string]bool{}
+	for _, candidate := range candidates {
+		representsGenre := false
+		for _, genre := range candidate.Genre {
+			if !coveredGenres[genre.GenreName] {
+				coveredGenres[genre.GenreName] = true
+				representsGenre = true
+			}
+		}
+		if representsGenre {
+			sample = append(sample, candidate)
+		}
+	}
+
+	return page(sample, 0, limit), nil
+}
+
+func (r *memoryTitleRepository[T]) update(imdbID string, version int64, apply func(*T)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.indexOfVersion(imdbID, version)
+	if err != nil {
+		return err
+	}
+	apply(&r.titles[i])
+	*r.fields.version(&r.titles[i])++
+
+	return nil
+}
+
+// indexOf finds the title outside the trash.
+func (r *memoryTitleRepository[T]) indexOf(imdbID string) int {
+	if i := r.indexOfStored(imdbID); i >= 0 && !r.deleted(&r.titles[i]) {
+		return i
+	}
+	return -1
+}
+
+// indexOfStored finds the title, in the trash or not.
+func (r *memoryTitleRepository[T]) indexOfStored(imdbID string) int {
+	for i := range r.titles {
+		if r.fields.imdbID(&r.titles[i]) == imdbID {
+			return i
+		}
+	}
+	return -1
+}
+
+// live returns the titles outside the trash.
+func (r *memoryTitleRepository[T]) live() []*T {
+	titles := []*T{}
+	for i := range r.titles {
+		if !r.deleted(&r.titles[i]) {
+			titles = append(titles, &r.titles[i])
+		}
+	}
+	return titles
+}
+
+func (r *memoryTitleRepository[T]) deleted(title *T) bool {
+	return r.fields.deletion(title).DeletedAt != nil
+}
+
+// duplicateOf is the error of a write clashing with the stored title at i.
+func (r *memoryTitleRepository[T]) duplicateOf(i int) error {
+	if r.deleted(&r.titles[i]) {
+		return ErrInTrash
+	}
+	return ErrDuplicate
+}
+
+// indexOfVersion finds the title a write applies to, checking its version
+// when version is not zero.
+func (r *memoryTitleRepository[T]) indexOfVersion(imdbID string, version int64) (int, error) {
+	i := r.indexOf(imdbID)
+	if i < 0 {
+		return i, ErrNotFound
+	}
+	if version != 0 && *r.fields.version(&r.titles[i]) != version {
+		return i, ErrVersionConflict
+	}
+	return i, nil
+}
+
+func (r *memoryTitleRepository[T]) summary(title *T) models.RecommendationItem {
+	item := r.fields.summary(title)
+	item.ContentType = r.contentType
+	return item
+}
+
+// within tells whether the date is set and within the range, as dateFilter
+// does.
+func within(date time.Time, between DateRange) bool {
+	return !date.IsZero() &&
+		(between.From.IsZero() || !date.Before(between.From)) &&
+		(between.To.IsZero() || !date.After(between.To))
+}
+
+// page applies skip and limit to the items, a limit of 0 keeps them all.
+func page[E any](items []E, skip, limit int64) []E {
+	if skip >= int64(len(items)) {
+		return []E{}
+	}
+	items = items[skip:]
+	if limit > 0 && limit < int64(len(items)) {
+		items = items[:limit]
+	}
+	return items
+}
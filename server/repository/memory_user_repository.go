@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	users []models.User
+}
+
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{}
+}
+
+func (r *memoryUserRepository) FindByEmail(_ context.Context, email string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return models.User{}, ErrNotFound
+}
+
+func (r *memoryUserRepository) ExistsWithEmail(ctx context.Context, email string) (bool, error) {
+	_, err := r.FindByEmail(ctx, email)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *memoryUserRepository) Insert(_ context.Context, user models.User) (bson.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == user.Email || existing.UserID == user.UserID {
+			return bson.NilObjectID, ErrDuplicate
+		}
+	}
+
+	if user.ID.IsZero() {
+		user.ID = bson.NewObjectID()
+	}
+	r.users = append(r.users, user)
+
+	return user.ID, nil
+}
+
+func (r *memoryUserRepository) UpdateTokens(_ context.Context, userId, token, refreshToken string) error {
+	return r.update(userId, func(user *models.User) {
+		user.Token = token
+		user.RefreshToken = refreshToken
+		user.UpdatedAt = time.Now()
+	})
+}
+
+func (r *memoryUserRepository) FavouriteGenres(_ context.Context, userId string) ([]models.Genre, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.UserID == userId {
+			return slices.Clone(user.FavouriteGenres), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memoryUserRepository) AddFavouriteGenres(ctx context.Context, userId string,
+	genres []models.Genre) ([]models.Genre, error) {
+
+	err := r.update(userId, func(user *models.User) {
+		favourites := slices.Clone(user.FavouriteGenres)
+		for _, genre := range genres {
+			if !slices.Contains(favourites, genre) {
+				favourites = append(favourites, genre)
+			}
+		}
+		user.FavouriteGenres = favourites
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.FavouriteGenres(ctx, userId)
+}
+
+func (r *memoryUserRepository) UpdateRole(_ context.Context, userId, role string) (string, error) {
+	var previous string
+	err := r.update(userId, func(user *models.User) {
+		previous = user.Role
+		user.Role = role
+		user.UpdatedAt = time.Now()
+	})
+
+	return previous, err
+}
+
+func (r *memoryUserRepository) update(userId string, apply func(*models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.users {
+		if r.users[i].UserID == userId {
+			apply(&r.users[i])
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
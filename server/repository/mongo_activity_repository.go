@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoRatingRepository struct {
+	collection *mongo.Collection
+}
+
+type mongoWatchlistRepository struct {
+	collection *mongo.Collection
+}
+
+type mongoTitleEventRepository struct {
+	collection *mongo.Collection
+}
+
+type mongoChartRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRatingRepository(collection *mongo.Collection) RatingRepository {
+	return &mongoRatingRepository{collection}
+}
+
+func NewMongoWatchlistRepository(collection *mongo.Collection) WatchlistRepository {
+	return &mongoWatchlistRepository{collection}
+}
+
+func NewMongoTitleEventRepository(collection *mongo.Collection) TitleEventRepository {
+	return &mongoTitleEventRepository{collection}
+}
+
+func NewMongoChartRepository(collection *mongo.Collection) ChartRepository {
+	return &mongoChartRepository{collection}
+}
+
+// Ratings
+// ---------------------------------------------------------------------------------------
+
+func (r *mongoRatingRepository) FindByUser(ctx context.Context, userId string) ([]models.Rating, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	return findAll[models.Rating](ctx, r.collection, bson.M{"user_id": userId}, opts)
+}
+
+func (r *mongoRatingRepository) FindLiked(ctx context.Context, imdbID string, userIds []string,
+	minScore int) ([]models.Rating, error) {
+
+	filter := bson.M{"score": bson.M{"$gte": minScore}}
+	if imdbID != "" {
+		filter["imdb_id"] = imdbID
+	}
+	if userIds != nil {
+		filter["user_id"] = bson.M{"$in": userIds}
+	}
+
+	return findAll[models.Rating](ctx, r.collection, filter)
+}
+
+func (r *mongoRatingRepository) Upsert(ctx context.Context, rating models.Rating) error {
+	now := time.Now()
+
+	filter := bson.M{"user_id": rating.UserID, "imdb_id": rating.ImdbID}
+	update := bson.M{
+		"$set": bson.M{
+			"content_type": rating.ContentType,
+			"score":        rating.Score,
+			"updated_at":   now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	return err
+}
+
+func (r *mongoRatingRepository) DeleteByTitles(ctx context.Context, contentType string, imdbIDs []string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"content_type": contentType, "imdb_id": bson.M{"$in": imdbIDs}})
+	return err
+}
+
+// Watchlist
+// ---------------------------------------------------------------------------------------
+
+func (r *mongoWatchlistRepository) FindByUser(ctx context.Context, userId string) ([]models.WatchlistItem, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "added_at", Value: -1}})
+	return findAll[models.WatchlistItem](ctx, r.collection, bson.M{"user_id": userId}, opts)
+}
+
+func (r *mongoWatchlistRepository) FindSaved(ctx context.Context, imdbID string,
+	userIds []string) ([]models.WatchlistItem, error) {
+
+	filter := bson.M{}
+	if imdbID != "" {
+		filter["imdb_id"] = imdbID
+	}
+	if userIds != nil {
+		filter["user_id"] = bson.M{"$in": userIds}
+	}
+
+	return findAll[models.WatchlistItem](ctx, r.collection, filter)
+}
+
+func (r *mongoWatchlistRepository) Add(ctx context.Context, item models.WatchlistItem) (bool, error) {
+	filter := bson.M{"user_id": item.UserID, "imdb_id": item.ImdbID}
+	update := bson.M{"$setOnInsert": bson.M{
+		"content_type": item.ContentType,
+		"added_at":     time.Now(),
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+
+	return result.UpsertedCount > 0, nil
+}
+
+func (r *mongoWatchlistRepository) Remove(ctx context.Context, userId, imdbID string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userId, "imdb_id": imdbID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *mongoWatchlistRepository) DeleteByTitles(ctx context.Context, contentType string, imdbIDs []string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"content_type": contentType, "imdb_id": bson.M{"$in": imdbIDs}})
+	return err
+}
+
+// Title events
+// ---------------------------------------------------------------------------------------
+
+func (r *mongoTitleEventRepository) Insert(ctx context.Context, event models.TitleEvent) error {
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+func (r *mongoTitleEventRepository) CountSince(ctx context.Context, contentType string,
+	since time.Time) ([]TitleEventCount, error) {
+
+	countEvents := func(eventType string) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$event_type", eventType}}, 1, 0}}}
+	}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.M{
+			"content_type": contentType,
+			"created_at":   bson.M{"$gte": since},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":       "$imdb_id",
+			"views":     countEvents(models.TitleEventView),
+			"watchlist": countEvents(models.TitleEventWatchlist),
+			"ratings":   countEvents(models.TitleEventRating),
+		}}},
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	return aggregateAll[TitleEventCount](ctx, r.collection, pipeline)
+}
+
+func (r *mongoTitleEventRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"created_at": bson.M{"$lt": before}})
+	return err
+}
+
+// Charts
+// ---------------------------------------------------------------------------------------
+
+func (r *mongoChartRepository) FindByID(ctx context.Context, chartID string) (models.Chart, error) {
+	var chart models.Chart
+	err := r.collection.FindOne(ctx, bson.M{"chart_id": chartID}).Decode(&chart)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return chart, ErrNotFound
+	}
+	return chart, err
+}
+
+func (r *mongoChartRepository) ReplaceAll(ctx context.Context, window, contentType string,
+	charts []models.Chart) error {
+
+	chartIDs := make([]string, 0, len(charts))
+	for _, chart := range charts {
+		chartIDs = append(chartIDs, chart.ChartID)
+
+		_, err := r.collection.ReplaceOne(ctx, bson.M{"chart_id": chart.ChartID}, chart,
+			options.Replace().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"window":       window,
+		"content_type": contentType,
+		"chart_id":     bson.M{"$nin": chartIDs},
+	})
+	return err
+}
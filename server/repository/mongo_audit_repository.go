@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoAuditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoAuditRepository(collection *mongo.Collection) AuditRepository {
+	return &mongoAuditRepository{collection}
+}
+
+func (r *mongoAuditRepository) Insert(ctx context.Context, entry models.AuditEntry) error {
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+func (r *mongoAuditRepository) Find(ctx context.Context, query AuditQuery) ([]models.AuditEntry, error) {
+	filter := bson.M{}
+	if query.ActorID != "" {
+		filter["actor_id"] = query.ActorID
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+	createdAt := bson.M{}
+	if !query.From.IsZero() {
+		createdAt["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		createdAt["$lt"] = query.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(query.Skip).
+		SetLimit(query.Limit)
+	return findAll[models.AuditEntry](ctx, r.collection, filter, opts)
+}
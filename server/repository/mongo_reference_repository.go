@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoGenreRepository struct {
+	collection *mongo.Collection
+}
+
+type mongoRankingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoGenreRepository(collection *mongo.Collection) GenreRepository {
+	return &mongoGenreRepository{collection}
+}
+
+func NewMongoRankingRepository(collection *mongo.Collection) RankingRepository {
+	return &mongoRankingRepository{collection}
+}
+
+func (r *mongoGenreRepository) FindAll(ctx context.Context) ([]models.Genre, error) {
+	return findAll[models.Genre](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoRankingRepository) FindAll(ctx context.Context) ([]models.Ranking, error) {
+	return findAll[models.Ranking](ctx, r.collection, bson.M{})
+}
+
+func (r *mongoGenreRepository) Upsert(ctx context.Context, genre models.Genre) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"genre_id": genre.GenreID}, genre,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *mongoGenreRepository) ReplaceAll(ctx context.Context, genres []models.Genre) error {
+	return replaceAll(ctx, r.collection, genres)
+}
+
+func (r *mongoRankingRepository) Upsert(ctx context.Context, ranking models.Ranking) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"ranking_value": ranking.RankingValue}, ranking,
+		options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *mongoRankingRepository) ReplaceAll(ctx context.Context, rankings []models.Ranking) error {
+	return replaceAll(ctx, r.collection, rankings)
+}
+
+// replaceAll swaps the collection for one holding documents alone. They
+// are inserted into a staging collection created with the options and
+// indexes of the collection, which is then renamed over it, so readers see
+// either every previous document or every new one. When a step fails the
+// staging collection is dropped and the collection is left untouched.
+func replaceAll[T any](ctx context.Context, collection *mongo.Collection, documents []T) error {
+	db := collection.Database()
+	staging := db.Collection(collection.Name() + "_replace_" + bson.NewObjectID().Hex())
+
+	err := createLike(ctx, collection, staging.Name())
+	if err == nil && len(documents) > 0 {
+		_, err = staging.InsertMany(ctx, documents)
+	}
+	if err == nil {
+		err = db.Client().Database("admin").RunCommand(ctx, bson.D{
+			{Key: "renameCollection", Value: db.Name() + "." + staging.Name()},
+			{Key: "to", Value: db.Name() + "." + collection.Name()},
+			{Key: "dropTarget", Value: true},
+		}).Err()
+	}
+	if err != nil {
+		// Dropped even when ctx is what made the replacement fail
+		if dropErr := staging.Drop(context.WithoutCancel(ctx)); dropErr != nil {
+			return errors.Join(err, fmt.Errorf("dropping %s: %w", staging.Name(), dropErr))
+		}
+		return err
+	}
+	return nil
+}
+
+// createLike creates the named collection with the options, such as the
+// schema validator, and the indexes of collection.
+func createLike(ctx context.Context, collection *mongo.Collection, name string) error {
+	db := collection.Database()
+
+	create := bson.D{{Key: "create", Value: name}}
+	specs, err := db.ListCollectionSpecifications(ctx, bson.M{"name": collection.Name()})
+	if err != nil {
+		return err
+	}
+	if len(specs) > 0 {
+		elements, err := specs[0].Options.Elements()
+		if err != nil {
+			return err
+		}
+		for _, element := range elements {
+			create = append(create, bson.E{Key: element.Key(), Value: element.Value()})
+		}
+	}
+	if err := db.RunCommand(ctx, create).Err(); err != nil {
+		return err
+	}
+
+	indexes, err := findIndexes(ctx, collection)
+	if err != nil || len(indexes) == 0 {
+		return err
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "createIndexes", Value: name},
+		{Key: "indexes", Value: indexes},
+	}).Err()
+}
+
+// findIndexes returns the specifications of the indexes of collection
+// other than the _id one, as createIndexes takes them.
+func findIndexes(ctx context.Context, collection *mongo.Collection) (bson.A, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// bson.D keeps the fields of compound keys in order
+	var specs []bson.D
+	if err := cursor.All(ctx, &specs); err != nil {
+		return nil, err
+	}
+
+	indexes := bson.A{}
+	for _, spec := range specs {
+		if slices.Contains(spec, bson.E{Key: "name", Value: "_id_"}) {
+			continue
+		}
+		indexes = append(indexes, slices.DeleteFunc(spec, func(e bson.E) bool { return e.Key == "ns" }))
+	}
+	return indexes, nil
+}
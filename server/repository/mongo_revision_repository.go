@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoRevisionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoRevisionRepository(collection *mongo.Collection) RevisionRepository {
+	return &mongoRevisionRepository{collection}
+}
+
+func (r *mongoRevisionRepository) Insert(ctx context.Context, revision models.Revision) error {
+	_, err := r.collection.InsertOne(ctx, revision)
+	return err
+}
+
+func (r *mongoRevisionRepository) FindByTitle(ctx context.Context, contentType,
+	imdbID string) ([]models.Revision, error) {
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetProjection(bson.M{"changes": 0, "document": 0})
+	return findAll[models.Revision](ctx, r.collection,
+		bson.M{"content_type": contentType, "imdb_id": imdbID}, opts)
+}
+
+func (r *mongoRevisionRepository) FindByID(ctx context.Context, id bson.ObjectID) (models.Revision, error) {
+	var revision models.Revision
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&revision)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return revision, ErrNotFound
+	}
+	return revision, err
+}
@@ -0,0 +1,783 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// mongoTitleRepository implements TitleRepository for the model stored in the
+// collection, movies and TV shows share the same document layout.
+type mongoTitleRepository[T any] struct {
+	collection  *mongo.Collection
+	contentType string
+	fields      titleAccessor[T]
+}
+
+type mongoMovieRepository struct {
+	*mongoTitleRepository[models.Movie]
+}
+
+type mongoTVShowRepository struct {
+	*mongoTitleRepository[models.TVShow]
+}
+
+func NewMongoMovieRepository(collection *mongo.Collection) MovieRepository {
+	return &mongoMovieRepository{&mongoTitleRepository[models.Movie]{collection, models.ContentTypeMovie, movieFields}}
+}
+
+func NewMongoTVShowRepository(collection *mongo.Collection) TVShowRepository {
+	return &mongoTVShowRepository{&mongoTitleRepository[models.TVShow]{collection, models.ContentTypeTVShow, tvShowFields}}
+}
+
+func (r *mongoTVShowRepository) FindSeason(ctx context.Context, imdbID string,
+	seasonNumber int) (models.Season, error) {
+
+	filter := notDeleted(bson.M{"imdb_id": imdbID, "seasons.season_number": seasonNumber})
+	opts := options.FindOne().SetProjection(bson.M{"_id": 0, "seasons.$": 1})
+
+	var tvShow struct {
+		Seasons []models.Season `bson:"seasons"`
+	}
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&tvShow)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && len(tvShow.Seasons) == 0) {
+		return models.Season{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Season{}, err
+	}
+	return tvShow.Seasons[0], nil
+}
+
+func (r *mongoTVShowRepository) FindEpisode(ctx context.Context, imdbID string, seasonNumber,
+	episodeNumber int) (models.Episode, error) {
+
+	filter := notDeleted(episodeFilter(seasonNumber, episodeNumber))
+	filter["imdb_id"] = imdbID
+
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$unwind", Value: "$seasons"}},
+		bson.D{{Key: "$match", Value: bson.M{"seasons.season_number": seasonNumber}}},
+		bson.D{{Key: "$unwind", Value: "$seasons.episodes"}},
+		bson.D{{Key: "$match", Value: bson.M{"seasons.episodes.episode_number": episodeNumber}}},
+		bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$seasons.episodes"}}},
+	}
+
+	episodes, err := aggregateAll[models.Episode](ctx, r.collection, pipeline)
+	if err != nil {
+		return models.Episode{}, err
+	}
+	if len(episodes) == 0 {
+		return models.Episode{}, ErrNotFound
+	}
+	return episodes[0], nil
+}
+
+func (r *mongoTVShowRepository) UpcomingEpisodes(ctx context.Context,
+	query UpcomingQuery) ([]models.UpcomingEpisode, error) {
+
+	airing := dateFilter(query.Between)
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: notDeleted(bson.M{
+			"status":  models.TVShowOngoing,
+			"seasons": bson.M{"$elemMatch": bson.M{"episodes": bson.M{"$elemMatch": bson.M{"air_date": airing}}}},
+		})}},
+		bson.D{{Key: "$unwind", Value: "$seasons"}},
+		bson.D{{Key: "$unwind", Value: "$seasons.episodes"}},
+		bson.D{{Key: "$match", Value: bson.M{"seasons.episodes.air_date": airing}}},
+		bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{
+			"$seasons.episodes",
+			bson.M{
+				"imdb_id":       "$imdb_id",
+				"title":         "$title",
+				"poster_path":   "$poster_path",
+				"season_number": "$seasons.season_number",
+			},
+		}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "air_date", Value: 1},
+			{Key: "imdb_id", Value: 1},
+			{Key: "season_number", Value: 1},
+			{Key: "episode_number", Value: 1},
+		}}},
+		bson.D{{Key: "$skip", Value: query.Skip}},
+	}
+	if query.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: query.Limit}})
+	}
+
+	return aggregateAll[models.UpcomingEpisode](ctx, r.collection, pipeline)
+}
+
+func (r *mongoTVShowRepository) AddSeason(ctx context.Context, imdbID string, season models.Season,
+	version int64) error {
+
+	filter := notDeleted(bson.M{
+		"imdb_id":               imdbID,
+		"seasons.season_number": bson.M{"$ne": season.SeasonNumber},
+	})
+	if version != 0 {
+		filter["version"] = version
+	}
+	update := bson.M{
+		"$push": bson.M{"seasons": season},
+		"$inc":  bson.M{"total_seasons": 1, "version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		if _, err := r.FindSeason(ctx, imdbID, season.SeasonNumber); err == nil {
+			return ErrDuplicate
+		}
+		return r.missedWrite(ctx, imdbID)
+	}
+
+	return nil
+}
+
+func (r *mongoTVShowRepository) UpdateSeason(ctx context.Context, imdbID string, season models.Season,
+	version int64) error {
+
+	update := bson.M{
+		"$set": bson.M{"seasons.$[s].episodes": season.Episodes},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.UpdateOne().SetArrayFilters([]any{bson.M{"s.season_number": season.SeasonNumber}})
+
+	return r.updateSeasons(ctx, imdbID, version, bson.M{"seasons.season_number": season.SeasonNumber}, update, opts)
+}
+
+func (r *mongoTVShowRepository) DeleteSeason(ctx context.Context, imdbID string, seasonNumber int,
+	version int64) error {
+
+	update := bson.M{
+		"$pull": bson.M{"seasons": bson.M{"season_number": seasonNumber}},
+		"$inc":  bson.M{"total_seasons": -1, "version": 1},
+	}
+
+	return r.updateSeasons(ctx, imdbID, version, bson.M{"seasons.season_number": seasonNumber}, update)
+}
+
+func (r *mongoTVShowRepository) AddEpisode(ctx context.Context, imdbID string, seasonNumber int,
+	episode models.Episode, version int64) error {
+
+	update := bson.M{
+		"$push": bson.M{"seasons.$[s].episodes": episode},
+		"$inc":  bson.M{"version": 1},
+	}
+	opts := options.UpdateOne().SetArrayFilters([]any{bson.M{"s.season_number": seasonNumber}})
+
+	return r.updateSeasons(ctx, imdbID, version, bson.M{"seasons.season_number": seasonNumber}, update, opts)
+}
+
+func (r *mongoTVShowRepository) UpdateEpisode(ctx context.Context, imdbID string, seasonNumber int,
+	episode models.Episode, version int64) error {
+
+	update := bson.M{
+		"$set": bson.M{"seasons.$[s].episodes.$[e]": episode},
+		"$inc": bson.M{"version": 1},
+	}
+	opts := options.UpdateOne().SetArrayFilters([]any{
+		bson.M{"s.season_number": seasonNumber},
+		bson.M{"e.episode_number": episode.EpisodeNumber},
+	})
+
+	return r.updateSeasons(ctx, imdbID, version, episodeFilter(seasonNumber, episode.EpisodeNumber), update, opts)
+}
+
+func (r *mongoTVShowRepository) DeleteEpisode(ctx context.Context, imdbID string, seasonNumber, episodeNumber int,
+	version int64) error {
+
+	// $pull cannot be combined with an update of the episodes left, an
+	// update pipeline removes the episode and renumbers the others at once
+	renumbered := bson.M{"$map": bson.M{
+		"input": bson.M{"$filter": bson.M{
+			"input": "$$s.episodes",
+			"as":    "e",
+			"cond":  bson.M{"$ne": bson.A{"$$e.episode_number", episodeNumber}},
+		}},
+		"as": "e",
+		"in": bson.M{"$mergeObjects": bson.A{"$$e", bson.M{"episode_number": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$$e.episode_number", episodeNumber}},
+			bson.M{"$subtract": bson.A{"$$e.episode_number", 1}},
+			"$$e.episode_number",
+		}}}}},
+	}}
+	update := bson.A{bson.M{"$set": bson.M{
+		"seasons": bson.M{"$map": bson.M{
+			"input": "$seasons",
+			"as":    "s",
+			"in": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$$s.season_number", seasonNumber}},
+				bson.M{"$mergeObjects": bson.A{"$$s", bson.M{"episodes": renumbered}}},
+				"$$s",
+			}},
+		}},
+		"version": bson.M{"$add": bson.A{"$version", 1}},
+	}}}
+
+	return r.updateSeasons(ctx, imdbID, version, episodeFilter(seasonNumber, episodeNumber), update)
+}
+
+// updateSeasons applies update to the TV show when it holds what target
+// matches, telling apart a missing show, season or episode from a version
+// conflict when nothing matched.
+func (r *mongoTVShowRepository) updateSeasons(ctx context.Context, imdbID string, version int64, target bson.M,
+	update any, opts ...options.Lister[options.UpdateOneOptions]) error {
+
+	filter := notDeleted(bson.M{"imdb_id": imdbID})
+	if version != 0 {
+		filter["version"] = version
+	}
+	for key, value := range target {
+		filter[key] = value
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, opts...)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	current, err := r.FindByImdbID(ctx, imdbID)
+	if err != nil {
+		return err
+	}
+	if version != 0 && current.Version != version {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+// episodeFilter matches the TV shows holding the episode.
+func episodeFilter(seasonNumber, episodeNumber int) bson.M {
+	return bson.M{"seasons": bson.M{"$elemMatch": bson.M{
+		"season_number":           seasonNumber,
+		"episodes.episode_number": episodeNumber,
+	}}}
+}
+
+func (r *mongoTitleRepository[T]) ContentType() string {
+	return r.contentType
+}
+
+func (r *mongoTitleRepository[T]) FindAll(ctx context.Context) ([]T, error) {
+	return findAll[T](ctx, r.collection, notDeleted(bson.M{}))
+}
+
+func (r *mongoTitleRepository[T]) FindBetween(ctx context.Context, between DateRange) ([]T, error) {
+	filter := notDeleted(bson.M{})
+	if !between.From.IsZero() || !between.To.IsZero() {
+		filter[r.fields.dateField] = dateFilter(between)
+	}
+	return findAll[T](ctx, r.collection, filter)
+}
+
+func (r *mongoTitleRepository[T]) FindByImdbID(ctx context.Context, imdbID string) (T, error) {
+	var title T
+	err := r.collection.FindOne(ctx, notDeleted(bson.M{"imdb_id": imdbID})).Decode(&title)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return title, ErrNotFound
+	}
+	return title, err
+}
+
+func (r *mongoTitleRepository[T]) FindByImdbIDs(ctx context.Context, imdbIDs []string) ([]T, error) {
+	// $indexOfArray keeps the order of imdbIDs
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: notDeleted(bson.M{"imdb_id": bson.M{"$in": imdbIDs}})}},
+		bson.D{{Key: "$addFields", Value: bson.M{"position": bson.M{"$indexOfArray": bson.A{imdbIDs, "$imdb_id"}}}}},
+		bson.D{{Key: "$sort", Value: bson.M{"position": 1}}},
+		bson.D{{Key: "$project", Value: bson.M{"position": 0}}},
+	}
+	return aggregateAll[T](ctx, r.collection, pipeline)
+}
+
+func (r *mongoTitleRepository[T]) Exists(ctx context.Context, imdbID string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, notDeleted(bson.M{"imdb_id": imdbID}))
+	return count > 0, err
+}
+
+func (r *mongoTitleRepository[T]) ExistsWithTitle(ctx context.Context, title string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, notDeleted(bson.M{"title": title}))
+	return count > 0, err
+}
+
+func (r *mongoTitleRepository[T]) Insert(ctx context.Context, title T) (bson.ObjectID, error) {
+	*r.fields.version(&title) = 1
+	*r.fields.deletion(&title) = models.Deletion{}
+
+	result, err := r.collection.InsertOne(ctx, title)
+	if err != nil {
+		return bson.NilObjectID, r.duplicateTitleError(ctx, &title, err)
+	}
+
+	id, _ := result.InsertedID.(bson.ObjectID)
+	return id, nil
+}
+
+func (r *mongoTitleRepository[T]) Replace(ctx context.Context, imdbID string, title T,
+	version int64) (int64, error) {
+
+	// An unconditional replace still goes through the version filter, so a
+	// write landing in between is reported instead of lost
+	if version == 0 {
+		current, err := r.FindByImdbID(ctx, imdbID)
+		if err != nil {
+			return 0, err
+		}
+		version = *r.fields.version(&current)
+	}
+	*r.fields.version(&title) = version + 1
+	*r.fields.deletion(&title) = models.Deletion{}
+
+	filter := notDeleted(bson.M{"imdb_id": imdbID, "version": version})
+	result, err := r.collection.ReplaceOne(ctx, filter, title)
+	if err != nil {
+		return 0, r.duplicateTitleError(ctx, &title, err)
+	}
+	if result.MatchedCount == 0 {
+		return 0, r.missedWrite(ctx, imdbID)
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *mongoTitleRepository[T]) Delete(ctx context.Context, imdbID, deletedBy string, version int64) error {
+	filter := notDeleted(bson.M{"imdb_id": imdbID})
+	if version != 0 {
+		filter["version"] = version
+	}
+	update := bson.M{
+		"$set": bson.M{"deleted_at": time.Now(), "deleted_by": deletedBy},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return r.missedWrite(ctx, imdbID)
+	}
+
+	return nil
+}
+
+func (r *mongoTitleRepository[T]) UpdateReview(ctx context.Context, imdbID, adminReview string,
+	ranking models.Ranking, version int64) error {
+
+	filter := notDeleted(bson.M{"imdb_id": imdbID})
+	if version != 0 {
+		filter["version"] = version
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"admin_review": adminReview,
+			"ranking":      ranking,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return r.missedWrite(ctx, imdbID)
+	}
+
+	return nil
+}
+
+func (r *mongoTitleRepository[T]) FindDeleted(ctx context.Context) ([]T, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	return findAll[T](ctx, r.collection, bson.M{"deleted_at": bson.M{"$ne": nil}}, opts)
+}
+
+func (r *mongoTitleRepository[T]) DeletedBefore(ctx context.Context, before time.Time) ([]string, error) {
+	filter := bson.M{"deleted_at": bson.M{"$ne": nil, "$lt": before}}
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "imdb_id": 1})
+
+	deleted, err := findAll[struct {
+		ImdbID string `bson:"imdb_id"`
+	}](ctx, r.collection, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	imdbIDs := make([]string, 0, len(deleted))
+	for _, title := range deleted {
+		imdbIDs = append(imdbIDs, title.ImdbID)
+	}
+	return imdbIDs, nil
+}
+
+func (r *mongoTitleRepository[T]) Restore(ctx context.Context, imdbID string) (T, error) {
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var trashed T
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"imdb_id": imdbID, "deleted_at": bson.M{"$ne": nil}},
+		update, opts).Decode(&trashed)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return trashed, ErrNotFound
+	}
+	return trashed, err
+}
+
+func (r *mongoTitleRepository[T]) Purge(ctx context.Context, imdbIDs []string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"imdb_id":    bson.M{"$in": imdbIDs},
+		"deleted_at": bson.M{"$ne": nil},
+	})
+	return err
+}
+
+func (r *mongoTitleRepository[T]) ReplaceAll(ctx context.Context, titles []T) error {
+	documents := make([]T, len(titles))
+	for i, title := range titles {
+		*r.fields.version(&title) = 1
+		*r.fields.deletion(&title) = models.Deletion{}
+		documents[i] = title
+	}
+	return duplicateError(replaceAll(ctx, r.collection, documents))
+}
+
+// missedWrite tells why a write filtered by imdb_id and version matched
+// nothing: the title is gone or has another version.
+func (r *mongoTitleRepository[T]) missedWrite(ctx context.Context, imdbID string) error {
+	exists, err := r.Exists(ctx, imdbID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return ErrNotFound
+}
+
+// duplicateTitleError is duplicateError for a write of title, telling the
+// clashes with a title in the trash and with the _id of another title apart.
+func (r *mongoTitleRepository[T]) duplicateTitleError(ctx context.Context, title *T, err error) error {
+	err = duplicateError(err)
+	if !errors.Is(err, ErrDuplicate) {
+		return err
+	}
+
+	imdbID := r.fields.imdbID(title)
+	for _, clash := range []struct {
+		filter bson.M
+		err    error
+	}{
+		{bson.M{"imdb_id": imdbID, "deleted_at": bson.M{"$ne": nil}}, ErrInTrash},
+		{bson.M{"_id": *r.fields.id(title), "imdb_id": bson.M{"$ne": imdbID}}, ErrIDTaken},
+	} {
+		count, countErr := r.collection.CountDocuments(ctx, clash.filter)
+		if countErr == nil && count > 0 {
+			return fmt.Errorf("%w: %v", clash.err, err)
+		}
+	}
+	return err
+}
+
+func (r *mongoTitleRepository[T]) Summaries(ctx context.Context, imdbIDs []string) ([]models.RecommendationItem, error) {
+	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.M{"imdb_id": bson.M{"$in": imdbIDs}}}},
+		bson.D{{Key: "$project", Value: summaryProjection}},
+	}
+	return r.aggregateSummaries(ctx, pipeline)
+}
+
+func (r *mongoTitleRepository[T]) Recommend(ctx context.Context, query RecommendationQuery) ([]models.RecommendationItem, error) {
+	return r.aggregateSummaries(ctx, buildRecommendationPipeline(query, time.Now()))
+}
+
+func (r *mongoTitleRepository[T]) Similar(ctx context.Context, query SimilarityQuery) ([]models.RecommendationItem, error) {
+	return r.aggregateSummaries(ctx, buildSimilarityPipeline(query))
+}
+
+func (r *mongoTitleRepository[T]) Sample(ctx context.Context, limit int64) ([]models.RecommendationItem, error) {
+	return r.aggregateSummaries(ctx, buildSamplePipeline(limit))
+}
+
+func (r *mongoTitleRepository[T]) aggregateSummaries(ctx context.Context, pipeline bson.A) ([]models.RecommendationItem, error) {
+	pipeline = append(bson.A{bson.D{{Key: "$match", Value: notDeleted(bson.M{})}}}, pipeline...)
+
+	items, err := aggregateAll[models.RecommendationItem](ctx, r.collection, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		items[i].ContentType = r.contentType
+	}
+	return items, nil
+}
+
+// dateFilter matches the dates within the range.
+func dateFilter(between DateRange) bson.M {
+	filter := bson.M{"$type": "date"}
+	if !between.From.IsZero() {
+		filter["$gte"] = between.From
+	}
+	if !between.To.IsZero() {
+		filter["$lte"] = between.To
+	}
+	return filter
+}
+
+// notDeleted narrows the filter to the titles outside the trash.
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = nil
+	return filter
+}
+
+// Pipelines
+// ---------------------------------------------------------------------------------------
+
+var summaryProjection = bson.M{
+	"_id":         0,
+	"imdb_id":     1,
+	"title":       1,
+	"poster_path": 1,
+	"genre":       1,
+	"ranking":     1,
+	"score":       1,
+}
+
+func buildRecommendationPipeline(query RecommendationQuery, now time.Time) bson.A {
+	weights := query.Weights
+	var pipeline bson.A
+
+	if len(query.FavouriteGenres) > 0 {
+		pipeline = bson.A{
+			bson.D{{Key: "$match", Value: bson.M{"genre.genre_name": bson.M{"$in": query.FavouriteGenres}}}},
+			bson.D{{Key: "$addFields", Value: bson.M{
+				"matched_genres": bson.M{"$size": bson.M{"$setIntersection": bson.A{
+					bson.M{"$ifNull": bson.A{"$genre.genre_name", bson.A{}}},
+					query.FavouriteGenres,
+				}}},
+				"ranking_score": rankingScoreExpr(),
+				"age_days":      ageDaysExpr(now),
+			}}},
+			bson.D{{Key: "$addFields", Value: bson.M{
+				"score": bson.M{"$add": bson.A{
+					bson.M{"$multiply": bson.A{weights.Genre, "$matched_genres"}},
+					bson.M{"$multiply": bson.A{weights.Ranking, "$ranking_score"}},
+					bson.M{"$multiply": bson.A{weights.Recency, recencyScoreExpr()}},
+				}},
+			}}},
+		}
+	} else {
+		pipeline = bson.A{
+			bson.D{{Key: "$addFields", Value: bson.M{
+				"ranking_score":  rankingScoreExpr(),
+				"trending_score": lookupScoreExpr(query.Trending),
+				"age_days":       ageDaysExpr(now),
+			}}},
+			bson.D{{Key: "$addFields", Value: bson.M{
+				"score": bson.M{"$add": bson.A{
+					bson.M{"$multiply": bson.A{weights.Ranking, "$ranking_score"}},
+					bson.M{"$multiply": bson.A{weights.Trending, "$trending_score"}},
+					bson.M{"$multiply": bson.A{weights.Recency, recencyScoreExpr()}},
+				}},
+			}}},
+		}
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "score", Value: -1},
+			{Key: "ranking.ranking_value", Value: 1},
+			{Key: "imdb_id", Value: 1},
+		}}},
+		bson.D{{Key: "$skip", Value: query.Skip}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+		bson.D{{Key: "$project", Value: summaryProjection}},
+	)
+}
+
+func buildSimilarityPipeline(query SimilarityQuery) bson.A {
+	source := query.Source
+	sourceGenres := genreNames(source.Genre)
+
+	coIDs := make([]string, 0, len(query.CoActivity))
+	for id := range query.CoActivity {
+		coIDs = append(coIDs, id)
+	}
+
+	candidateGenres := bson.M{"$ifNull": bson.A{"$genre.genre_name", bson.A{}}}
+	genreScore := bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$size": bson.M{"$setUnion": bson.A{candidateGenres, sourceGenres}}}, 0}},
+		0,
+		bson.M{"$divide": bson.A{
+			bson.M{"$size": bson.M{"$setIntersection": bson.A{candidateGenres, sourceGenres}}},
+			bson.M{"$size": bson.M{"$setUnion": bson.A{candidateGenres, sourceGenres}}},
+		}},
+	}}
+
+	var rankingScore any = 0
+	if IsRanked(source.Ranking.RankingValue) {
+		rankingScore = bson.M{"$cond": bson.A{
+			bson.M{"$and": bson.A{
+				bson.M{"$gt": bson.A{"$ranking.ranking_value", 0}},
+				bson.M{"$lt": bson.A{"$ranking.ranking_value", 999}},
+			}},
+			bson.M{"$divide": bson.A{1, bson.M{"$add": bson.A{1, bson.M{"$abs": bson.M{
+				"$subtract": bson.A{"$ranking.ranking_value", source.Ranking.RankingValue},
+			}}}}}},
+			0,
+		}}
+	}
+
+	return bson.A{
+		bson.D{{Key: "$match", Value: bson.M{
+			"imdb_id": bson.M{"$ne": source.ImdbID},
+			"$or": bson.A{
+				bson.M{"genre.genre_name": bson.M{"$in": sourceGenres}},
+				bson.M{"imdb_id": bson.M{"$in": coIDs}},
+			},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"score": bson.M{"$add": bson.A{
+				bson.M{"$multiply": bson.A{similarGenreWeight, genreScore}},
+				bson.M{"$multiply": bson.A{similarRankingWeight, rankingScore}},
+				bson.M{"$multiply": bson.A{similarCoActivityWeight, lookupScoreExpr(query.CoActivity)}},
+			}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "imdb_id", Value: 1}}}},
+		bson.D{{Key: "$limit", Value: query.Limit}},
+		bson.D{{Key: "$project", Value: summaryProjection}},
+	}
+}
+
+// buildSamplePipeline draws a random pool of titles, keeps one per genre and
+// returns up to limit of them in random order.
+func buildSamplePipeline(limit int64) bson.A {
+	return bson.A{
+		bson.D{{Key: "$sample", Value: bson.M{"size": sampleCandidates}}},
+		bson.D{{Key: "$addFields", Value: bson.M{"all_genres": "$genre"}}},
+		bson.D{{Key: "$unwind", Value: "$genre"}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$genre.genre_name", "title": bson.M{"$first": "$$ROOT"}}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$title.imdb_id", "title": bson.M{"$first": "$title"}}}},
+		bson.D{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$title"}}},
+		bson.D{{Key: "$sample", Value: bson.M{"size": limit}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":         0,
+			"imdb_id":     1,
+			"title":       1,
+			"poster_path": 1,
+			"genre":       "$all_genres",
+			"ranking":     1,
+		}}},
+	}
+}
+
+// rankingScoreExpr is the inverse of the ranking value, 0 for unranked titles.
+func rankingScoreExpr() bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$and": bson.A{
+			bson.M{"$gt": bson.A{"$ranking.ranking_value", 0}},
+			bson.M{"$lt": bson.A{"$ranking.ranking_value", 999}},
+		}},
+		bson.M{"$divide": bson.A{1, "$ranking.ranking_value"}},
+		0,
+	}}
+}
+
+// ageDaysExpr is the number of days since the title was added to the catalog.
+func ageDaysExpr(now time.Time) bson.M {
+	return bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{now, bson.M{"$toDate": "$_id"}}},
+		millisPerDay,
+	}}
+}
+
+// recencyScoreExpr decays from 1 with the age_days field set by ageDaysExpr.
+func recencyScoreExpr() bson.M {
+	return bson.M{"$divide": bson.A{
+		1,
+		bson.M{"$add": bson.A{1, bson.M{"$divide": bson.A{"$age_days", recencyHalfLifeDays}}}},
+	}}
+}
+
+// lookupScoreExpr resolves the title's score from a map computed in Go, 0 when
+// the title is not in it.
+func lookupScoreExpr(scores map[string]float64) bson.M {
+	ids := make([]string, 0, len(scores))
+	values := make([]float64, 0, len(scores))
+	for id, score := range scores {
+		ids = append(ids, id)
+		values = append(values, score)
+	}
+
+	return bson.M{"$let": bson.M{
+		"vars": bson.M{"idx": bson.M{"$indexOfArray": bson.A{ids, "$imdb_id"}}},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$gte": bson.A{"$$idx", 0}},
+			bson.M{"$arrayElemAt": bson.A{values, "$$idx"}},
+			0,
+		}},
+	}}
+}
+
+// Helpers
+// ---------------------------------------------------------------------------------------
+
+func findAll[T any](ctx context.Context, collection *mongo.Collection, filter any,
+	opts ...options.Lister[options.FindOptions]) ([]T, error) {
+
+	cursor, err := collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []T{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// duplicateError turns the duplicate key errors of the unique indexes into
+// ErrDuplicate.
+func duplicateError(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: %v", ErrDuplicate, err)
+	}
+	return err
+}
+
+func aggregateAll[T any](ctx context.Context, collection *mongo.Collection, pipeline bson.A) ([]T, error) {
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := []T{}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
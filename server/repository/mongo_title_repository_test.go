@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// stageOperators lists the operator of every stage of the pipeline.
+func stageOperators(pipeline bson.A) []string {
+	operators := []string{}
+	for _, stage := range pipeline {
+		operators = append(operators, stage.(bson.D)[0].Key)
+	}
+	return operators
+}
+
+// stageValue is the value of the first stage of the pipeline with the
+// operator, nil when there is none.
+func stageValue(pipeline bson.A, operator string) any {
+	for _, stage := range pipeline {
+		if stage.(bson.D)[0].Key == operator {
+			return stage.(bson.D)[0].Value
+		}
+	}
+	return nil
+}
+
+// scoreTerms are the weighted terms the pipeline adds up into the score.
+func scoreTerms(pipeline bson.A) bson.A {
+	for _, stage := range pipeline {
+		fields, ok := stage.(bson.D)[0].Value.(bson.M)
+		if score, scored := fields["score"]; ok && scored {
+			return score.(bson.M)["$add"].(bson.A)
+		}
+	}
+	return nil
+}
+
+func TestBuildRecommendationPipeline(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	weights := RecommendationWeights{Genre: 3, Ranking: 2, Recency: 1, Trending: 4}
+
+	tests := []struct {
+		name      string
+		query     RecommendationQuery
+		operators []string
+		match     any
+		terms     bson.A
+	}{
+		{
+			name: "favourite genres",
+			query: RecommendationQuery{
+				FavouriteGenres: []string{"Action", "Comedy"},
+				Weights:         weights,
+				Skip:            20,
+				Limit:           10,
+			},
+			operators: []string{"$match", "$addFields", "$addFields", "$sort", "$skip", "$limit", "$project"},
+			match:     bson.M{"genre.genre_name": bson.M{"$in": []string{"Action", "Comedy"}}},
+			terms: bson.A{
+				bson.M{"$multiply": bson.A{3.0, "$matched_genres"}},
+				bson.M{"$multiply": bson.A{2.0, "$ranking_score"}},
+				bson.M{"$multiply": bson.A{1.0, recencyScoreExpr()}},
+			},
+		},
+		{
+			name: "trending without favourite genres",
+			query: RecommendationQuery{
+				Trending: map[string]float64{"tt0000001": 1},
+				Weights:  weights,
+				Skip:     20,
+				Limit:    10,
+			},
+			operators: []string{"$addFields", "$addFields", "$sort", "$skip", "$limit", "$project"},
+			terms: bson.A{
+				bson.M{"$multiply": bson.A{2.0, "$ranking_score"}},
+				bson.M{"$multiply": bson.A{4.0, "$trending_score"}},
+				bson.M{"$multiply": bson.A{1.0, recencyScoreExpr()}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeline := buildRecommendationPipeline(tt.query, now)
+
+			assert.Equal(t, tt.operators, stageOperators(pipeline))
+			assert.Equal(t, tt.match, stageValue(pipeline, "$match"))
+			assert.Equal(t, tt.terms, scoreTerms(pipeline))
+			// Ties on the score go to the better ranked title, then the IMDB ID,
+			// so that pages skip over a stable order
+			assert.Equal(t, bson.D{
+				{Key: "score", Value: -1},
+				{Key: "ranking.ranking_value", Value: 1},
+				{Key: "imdb_id", Value: 1},
+			}, stageValue(pipeline, "$sort"))
+			assert.Equal(t, int64(20), stageValue(pipeline, "$skip"))
+			assert.Equal(t, int64(10), stageValue(pipeline, "$limit"))
+			assert.Equal(t, summaryProjection, stageValue(pipeline, "$project"))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type mongoUserRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoUserRepository(collection *mongo.Collection) UserRepository {
+	return &mongoUserRepository{collection}
+}
+
+func (r *mongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	var user models.User
+	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return user, ErrNotFound
+	}
+	return user, err
+}
+
+func (r *mongoUserRepository) ExistsWithEmail(ctx context.Context, email string) (bool, error) {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"email": email})
+	return count > 0, err
+}
+
+func (r *mongoUserRepository) Insert(ctx context.Context, user models.User) (bson.ObjectID, error) {
+	result, err := r.collection.InsertOne(ctx, user)
+	if err != nil {
+		return bson.NilObjectID, duplicateError(err)
+	}
+
+	id, _ := result.InsertedID.(bson.ObjectID)
+	return id, nil
+}
+
+func (r *mongoUserRepository) UpdateTokens(ctx context.Context, userId, token, refreshToken string) error {
+	updateAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
+
+	updateData := bson.M{
+		"$set": bson.M{
+			"token":         token,
+			"refresh_token": refreshToken,
+			"update_at":     updateAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"user_id": userId}, updateData)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *mongoUserRepository) FavouriteGenres(ctx context.Context, userId string) ([]models.Genre, error) {
+	opts := options.FindOne().SetProjection(bson.M{"favourite_genres": 1, "_id": 0})
+
+	var result struct {
+		FavouriteGenres []models.Genre `bson:"favourite_genres"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"user_id": userId}, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+
+	return result.FavouriteGenres, err
+}
+
+func (r *mongoUserRepository) AddFavouriteGenres(ctx context.Context, userId string,
+	genres []models.Genre) ([]models.Genre, error) {
+
+	update := bson.M{"$addToSet": bson.M{"favourite_genres": bson.M{"$each": genres}}}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"favourite_genres": 1})
+
+	var result struct {
+		FavouriteGenres []models.Genre `bson:"favourite_genres"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"user_id": userId}, update, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+
+	return result.FavouriteGenres, err
+}
+
+func (r *mongoUserRepository) UpdateRole(ctx context.Context, userId, role string) (string, error) {
+	update := bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.Before).
+		SetProjection(bson.M{"role": 1})
+
+	var previous struct {
+		Role string `bson:"role"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"user_id": userId}, update, opts).Decode(&previous)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", ErrNotFound
+	}
+
+	return previous.Role, err
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	// ErrNotFound is returned when the document to read or modify does not exist.
+	ErrNotFound = errors.New("document not found")
+	// ErrDuplicate is returned when a write would break a unique index, as
+	// a second title with the same imdb_id or a second user with the same email.
+	ErrDuplicate = errors.New("duplicate document")
+	// ErrInTrash is the ErrDuplicate returned when the title with the same
+	// imdb_id is in the trash, where the unique index still covers it.
+	ErrInTrash = fmt.Errorf("%w: title in the trash", ErrDuplicate)
+	// ErrIDTaken is the ErrDuplicate returned when the _id given to a new
+	// title already belongs to another one.
+	ErrIDTaken = fmt.Errorf("%w: _id taken", ErrDuplicate)
+	// ErrVersionConflict is returned when a conditional write expects a
+	// version other than the stored one.
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// TitleCatalog is the part of a movie or TV show repository that does not
+// depend on the concrete model, so handlers can work on either content type.
+type TitleCatalog interface {
+	ContentType() string
+	Exists(ctx context.Context, imdbID string) (bool, error)
+	// Summaries returns the summary of every listed title that exists.
+	Summaries(ctx context.Context, imdbIDs []string) ([]models.RecommendationItem, error)
+	Recommend(ctx context.Context, query RecommendationQuery) ([]models.RecommendationItem, error)
+	Similar(ctx context.Context, query SimilarityQuery) ([]models.RecommendationItem, error)
+	// Sample returns up to limit random titles, at most one per genre.
+	Sample(ctx context.Context, limit int64) ([]models.RecommendationItem, error)
+}
+
+// TitleRepository holds the operations movies and TV shows have in common.
+// Titles carry a version that starts at 1 and grows with every write; the
+// writes given a non zero version only apply when it is the stored one.
+// Deleted titles stay in the trash, which every method but the trash ones
+// ignores, until they are restored or purged.
+type TitleRepository[T any] interface {
+	TitleCatalog
+	FindAll(ctx context.Context) ([]T, error)
+	// FindBetween returns the titles released, or first aired for TV shows,
+	// within the range.
+	FindBetween(ctx context.Context, between DateRange) ([]T, error)
+	FindByImdbID(ctx context.Context, imdbID string) (T, error)
+	// FindByImdbIDs returns the listed titles in the order of imdbIDs.
+	FindByImdbIDs(ctx context.Context, imdbIDs []string) ([]T, error)
+	ExistsWithTitle(ctx context.Context, title string) (bool, error)
+	Insert(ctx context.Context, title T) (bson.ObjectID, error)
+	// Replace overwrites the title and returns how many documents changed.
+	// The version of title is ignored, the stored one is incremented.
+	Replace(ctx context.Context, imdbID string, title T, version int64) (int64, error)
+	// Delete moves the title to the trash.
+	Delete(ctx context.Context, imdbID, deletedBy string, version int64) error
+	UpdateReview(ctx context.Context, imdbID, adminReview string, ranking models.Ranking, version int64) error
+
+	// FindDeleted returns the titles in the trash, last deleted first.
+	FindDeleted(ctx context.Context) ([]T, error)
+	// DeletedBefore returns the IMDB IDs of the titles moved to the trash
+	// before the given time.
+	DeletedBefore(ctx context.Context, before time.Time) ([]string, error)
+	// Restore takes the title out of the trash and returns it as it was in
+	// the trash.
+	Restore(ctx context.Context, imdbID string) (T, error)
+	// Purge removes the listed titles from the trash for good.
+	Purge(ctx context.Context, imdbIDs []string) error
+	// ReplaceAll makes titles the only ones, emptying the trash, as new
+	// titles keeping their _id. Either every title is written or the stored
+	// ones are left as they were.
+	ReplaceAll(ctx context.Context, titles []T) error
+}
+
+type MovieRepository interface {
+	TitleRepository[models.Movie]
+}
+
+type TVShowRepository interface {
+	TitleRepository[models.TVShow]
+	// FindSeason returns a season of the TV show without loading the others.
+	FindSeason(ctx context.Context, imdbID string, seasonNumber int) (models.Season, error)
+	// FindEpisode returns an episode of the TV show without loading the
+	// seasons.
+	FindEpisode(ctx context.Context, imdbID string, seasonNumber, episodeNumber int) (models.Episode, error)
+	// UpcomingEpisodes returns the episodes of the ongoing shows airing
+	// within query.Between, soonest first.
+	UpcomingEpisodes(ctx context.Context, query UpcomingQuery) ([]models.UpcomingEpisode, error)
+
+	// The season and episode writes below apply in place to the TV show
+	// at the given version, or whatever its version when it is zero. They
+	// return ErrNotFound when the show, season or episode is missing.
+
+	// AddSeason appends the season and increments total_seasons, or returns
+	// ErrDuplicate when the show already has a season with the same number.
+	AddSeason(ctx context.Context, imdbID string, season models.Season, version int64) error
+	// UpdateSeason replaces the episodes of the season with the same number.
+	UpdateSeason(ctx context.Context, imdbID string, season models.Season, version int64) error
+	// DeleteSeason removes the season and decrements total_seasons.
+	DeleteSeason(ctx context.Context, imdbID string, seasonNumber int, version int64) error
+	// AddEpisode appends the episode to the season.
+	AddEpisode(ctx context.Context, imdbID string, seasonNumber int, episode models.Episode, version int64) error
+	// UpdateEpisode replaces the episode with the same number.
+	UpdateEpisode(ctx context.Context, imdbID string, seasonNumber int, episode models.Episode, version int64) error
+	// DeleteEpisode removes the episode and numbers the ones after it one
+	// lower, keeping the numbering contiguous.
+	DeleteEpisode(ctx context.Context, imdbID string, seasonNumber, episodeNumber int, version int64) error
+}
+
+type UserRepository interface {
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	ExistsWithEmail(ctx context.Context, email string) (bool, error)
+	Insert(ctx context.Context, user models.User) (bson.ObjectID, error)
+	UpdateTokens(ctx context.Context, userId, token, refreshToken string) error
+	FavouriteGenres(ctx context.Context, userId string) ([]models.Genre, error)
+	// AddFavouriteGenres adds the genres the user does not have yet and
+	// returns the resulting favourites.
+	AddFavouriteGenres(ctx context.Context, userId string, genres []models.Genre) ([]models.Genre, error)
+	// UpdateRole sets the role of the user and returns the one it replaced.
+	UpdateRole(ctx context.Context, userId, role string) (string, error)
+}
+
+type GenreRepository interface {
+	FindAll(ctx context.Context) ([]models.Genre, error)
+	// Upsert creates or replaces the genre with the same genre_id.
+	Upsert(ctx context.Context, genre models.Genre) error
+	// ReplaceAll makes genres the only genres.
+	ReplaceAll(ctx context.Context, genres []models.Genre) error
+}
+
+type RankingRepository interface {
+	FindAll(ctx context.Context) ([]models.Ranking, error)
+	// Upsert creates or replaces the ranking with the same ranking_value.
+	Upsert(ctx context.Context, ranking models.Ranking) error
+	// ReplaceAll makes rankings the only rankings.
+	ReplaceAll(ctx context.Context, rankings []models.Ranking) error
+}
+
+type RatingRepository interface {
+	FindByUser(ctx context.Context, userId string) ([]models.Rating, error)
+	// FindLiked returns the ratings of at least minScore, of the title when
+	// imdbID is set and by the listed users when userIds is not nil.
+	FindLiked(ctx context.Context, imdbID string, userIds []string, minScore int) ([]models.Rating, error)
+	// Upsert creates or updates the user's rating of the title.
+	Upsert(ctx context.Context, rating models.Rating) error
+	// DeleteByTitles removes the ratings of the listed titles.
+	DeleteByTitles(ctx context.Context, contentType string, imdbIDs []string) error
+}
+
+type WatchlistRepository interface {
+	FindByUser(ctx context.Context, userId string) ([]models.WatchlistItem, error)
+	// FindSaved returns the watchlist items of the title when imdbID is set
+	// and of the listed users when userIds is not nil.
+	FindSaved(ctx context.Context, imdbID string, userIds []string) ([]models.WatchlistItem, error)
+	// Add returns false when the title was already in the user's watchlist.
+	Add(ctx context.Context, item models.WatchlistItem) (bool, error)
+	Remove(ctx context.Context, userId, imdbID string) error
+	// DeleteByTitles removes the listed titles from every watchlist.
+	DeleteByTitles(ctx context.Context, contentType string, imdbIDs []string) error
+}
+
+type TitleEventRepository interface {
+	Insert(ctx context.Context, event models.TitleEvent) error
+	// CountSince counts the events of each title of a content type since the
+	// given time.
+	CountSince(ctx context.Context, contentType string, since time.Time) ([]TitleEventCount, error)
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+type ChartRepository interface {
+	FindByID(ctx context.Context, chartID string) (models.Chart, error)
+	// ReplaceAll stores the charts of a window and content type, removing
+	// the ones of that window and content type not in charts.
+	ReplaceAll(ctx context.Context, window, contentType string, charts []models.Chart) error
+}
+
+type RevisionRepository interface {
+	Insert(ctx context.Context, revision models.Revision) error
+	// FindByTitle returns the revisions of a title, newest first, without
+	// their changes and documents.
+	FindByTitle(ctx context.Context, contentType, imdbID string) ([]models.Revision, error)
+	FindByID(ctx context.Context, id bson.ObjectID) (models.Revision, error)
+}
+
+type AuditRepository interface {
+	Insert(ctx context.Context, entry models.AuditEntry) error
+	// Find returns the entries matching the query, newest first.
+	Find(ctx context.Context, query AuditQuery) ([]models.AuditEntry, error)
+}
+
+// AuditQuery filters the audit log. Empty fields match every entry; From is
+// inclusive and To exclusive.
+type AuditQuery struct {
+	ActorID string
+	Action  string
+	From    time.Time
+	To      time.Time
+	Skip    int64
+	Limit   int64
+}
+
+// DateRange bounds a date on both ends, inclusive. A zero bound leaves that
+// end open; titles without a date only match a range open on both ends.
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// UpcomingQuery pages through the upcoming episodes.
+type UpcomingQuery struct {
+	Between DateRange
+	Skip    int64
+	Limit   int64
+}
+
+// TitleEventCount is the number of events of each type a title received.
+type TitleEventCount struct {
+	ImdbID    string `bson:"_id"`
+	Views     int    `bson:"views"`
+	Watchlist int    `bson:"watchlist"`
+	Ratings   int    `bson:"ratings"`
+}
+
+// Repositories bundles every repository the handlers depend on.
+type Repositories struct {
+	Movies    MovieRepository
+	TVShows   TVShowRepository
+	Users     UserRepository
+	Genres    GenreRepository
+	Rankings  RankingRepository
+	Ratings   RatingRepository
+	Watchlist WatchlistRepository
+	Events    TitleEventRepository
+	Charts    ChartRepository
+	Revisions RevisionRepository
+	Audit     AuditRepository
+	Health    HealthChecker
+}
+
+// Catalog returns the repository of the given content type, nil when the
+// content type is unknown.
+func (r *Repositories) Catalog(contentType string) TitleCatalog {
+	switch contentType {
+	case models.ContentTypeMovie:
+		return r.Movies
+	case models.ContentTypeTVShow:
+		return r.TVShows
+	default:
+		return nil
+	}
+}
+
+func NewMongoRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Movies:    NewMongoMovieRepository(db.Collection("movies")),
+		TVShows:   NewMongoTVShowRepository(db.Collection("tv_shows")),
+		Users:     NewMongoUserRepository(db.Collection("users")),
+		Genres:    NewMongoGenreRepository(db.Collection("genres")),
+		Rankings:  NewMongoRankingRepository(db.Collection("rankings")),
+		Ratings:   NewMongoRatingRepository(db.Collection("ratings")),
+		Watchlist: NewMongoWatchlistRepository(db.Collection("watchlist")),
+		Events:    NewMongoTitleEventRepository(db.Collection("title_events")),
+		Charts:    NewMongoChartRepository(db.Collection("charts")),
+		Revisions: NewMongoRevisionRepository(db.Collection("revisions")),
+		Audit:     NewMongoAuditRepository(db.Collection("audit_log")),
+		Health:    NewMongoHealthChecker(db),
+	}
+}
+
+// NewMemoryRepositories returns empty in-memory repositories, meant for tests.
+func NewMemoryRepositories() *Repositories {
+	return &Repositories{
+		Movies:    NewMemoryMovieRepository(),
+		TVShows:   NewMemoryTVShowRepository(),
+		Users:     NewMemoryUserRepository(),
+		Genres:    NewMemoryGenreRepository(),
+		Rankings:  NewMemoryRankingRepository(),
+		Ratings:   NewMemoryRatingRepository(),
+		Watchlist: NewMemoryWatchlistRepository(),
+		Events:    NewMemoryTitleEventRepository(),
+		Charts:    NewMemoryChartRepository(),
+		Revisions: NewMemoryRevisionRepository(),
+		Audit:     NewMemoryAuditRepository(),
+		Health:    NewMemoryHealthChecker(),
+	}
+}
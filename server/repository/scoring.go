@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"math"
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// RecommendationWeights controls how much each signal contributes to the
+// score used to order recommended titles.
+type RecommendationWeights struct {
+	Genre    float64
+	Ranking  float64
+	Recency  float64
+	Trending float64
+}
+
+// RecommendationQuery selects and orders the titles to recommend. Titles
+// matching any favourite genre are scored by:
+//   - the number of favourite genres they match,
+//   - the inverse of their ranking value (1 is the best ranking, 999 means unranked),
+//   - how recently they were added to the catalog, decaying with recencyHalfLifeDays.
+//
+// Without favourite genres the whole catalog is scored by ranking, trending
+// and recency instead.
+type RecommendationQuery struct {
+	FavouriteGenres []string
+	// Trending maps IMDB IDs to a score between 0 and 1, used when there are
+	// no favourite genres.
+	Trending map[string]float64
+	Weights  RecommendationWeights
+	Skip     int64
+	Limit    int64
+}
+
+// SimilarityQuery scores candidates against the source title by:
+//   - the Jaccard index of their genres,
+//   - how close their ranking values are, when both are ranked,
+//   - the share of the source's audience that also liked or saved them.
+//
+// Candidates must share a genre or an audience with the source.
+type SimilarityQuery struct {
+	Source models.RecommendationItem
+	// CoActivity maps IMDB IDs to the share of the source's audience that
+	// liked or saved them.
+	CoActivity map[string]float64
+	Limit      int64
+}
+
+// Weights of the signals that make two titles similar. Shared audience weighs
+// the most since it reflects actual taste rather than metadata.
+const (
+	similarGenreWeight      = 2.0
+	similarRankingWeight    = 1.0
+	similarCoActivityWeight = 3.0
+)
+
+const (
+	// recencyHalfLifeDays is the age at which a title's recency score drops to half.
+	recencyHalfLifeDays = 180.0
+	millisPerDay        = 24 * 60 * 60 * 1000
+
+	// sampleCandidates is how many random titles the onboarding sample picks
+	// its one-per-genre representatives from.
+	sampleCandidates = 200
+)
+
+func IsRanked(rankingValue int) bool {
+	return rankingValue > 0 && rankingValue < 999
+}
+
+func rankingScore(rankingValue int) float64 {
+	if !IsRanked(rankingValue) {
+		return 0
+	}
+	return 1 / float64(rankingValue)
+}
+
+func recencyScore(id bson.ObjectID, now time.Time) float64 {
+	ageDays := float64(now.Sub(id.Timestamp()).Milliseconds()) / millisPerDay
+	return 1 / (1 + ageDays/recencyHalfLifeDays)
+}
+
+func rankingProximity(source, candidate int) float64 {
+	if !IsRanked(source) || !IsRanked(candidate) {
+		return 0
+	}
+	return 1 / (1 + math.Abs(float64(candidate-source)))
+}
+
+func genreNames(genres []models.Genre) []string {
+	names := make([]string, 0, len(genres))
+	for _, genre := range genres {
+		names = append(names, genre.GenreName)
+	}
+	return names
+}
+
+func countShared(genres []models.Genre, names []string) int {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	shared := 0
+	seen := map[string]bool{}
+	for _, genre := range genres {
+		if wanted[genre.GenreName] && !seen[genre.GenreName] {
+			seen[genre.GenreName] = true
+			shared++
+		}
+	}
+	return shared
+}
+
+func genreJaccard(genres []models.Genre, names []string) float64 {
+	union := map[string]bool{}
+	for _, name := range names {
+		union[name] = true
+	}
+	for _, genre := range genres {
+		union[genre.GenreName] = true
+	}
+	if len(union) == 0 {
+		return 0
+	}
+	return float64(countShared(genres, names)) / float64(len(union))
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"server/models"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// titleAccessor exposes the fields the repositories need to read and write
+// on a movie or TV show.
+type titleAccessor[T any] struct {
+	id        func(*T) *bson.ObjectID
+	version   func(*T) *int64
+	deletion  func(*T) *models.Deletion
+	imdbID    func(*T) string
+	title     func(*T) string
+	summary   func(*T) models.RecommendationItem
+	setReview func(*T, string, models.Ranking)
+	// date is the day the title came out, stored under dateField.
+	date      func(*T) time.Time
+	dateField string
+}
+
+var movieFields = titleAccessor[models.Movie]{
+	id:       func(m *models.Movie) *bson.ObjectID { return &m.ID },
+	version:  func(m *models.Movie) *int64 { return &m.Version },
+	deletion: func(m *models.Movie) *models.Deletion { return &m.Deletion },
+	imdbID:   func(m *models.Movie) string { return m.ImdbID },
+	title:    func(m *models.Movie) string { return m.Title },
+	summary: func(m *models.Movie) models.RecommendationItem {
+		return models.RecommendationItem{
+			ImdbID: m.ImdbID, Title: m.Title, PosterPath: m.PosterPath, Genre: m.Genre, Ranking: m.Ranking,
+		}
+	},
+	setReview: func(m *models.Movie, review string, ranking models.Ranking) {
+		m.AdminReview, m.Ranking = review, ranking
+	},
+	date:      func(m *models.Movie) time.Time { return m.ReleaseDate.Time },
+	dateField: "release_date",
+}
+
+var tvShowFields = titleAccessor[models.TVShow]{
+	id:       func(s *models.TVShow) *bson.ObjectID { return &s.ID },
+	version:  func(s *models.TVShow) *int64 { return &s.Version },
+	deletion: func(s *models.TVShow) *models.Deletion { return &s.Deletion },
+	imdbID:   func(s *models.TVShow) string { return s.ImdbID },
+	title:    func(s *models.TVShow) string { return s.Title },
+	summary: func(s *models.TVShow) models.RecommendationItem {
+		return models.RecommendationItem{
+			ImdbID: s.ImdbID, Title: s.Title, PosterPath: s.PosterPath, Genre: s.Genre, Ranking: s.Ranking,
+		}
+	},
+	setReview: func(s *models.TVShow, review string, ranking models.Ranking) {
+		s.AdminReview, s.Ranking = review, ranking
+	},
+	date:      func(s *models.TVShow) time.Time { return s.FirstAired.Time },
+	dateField: "first_aired",
+}
@@ -3,30 +3,86 @@ package routes
 import (
 	"server/controllers"
 	"server/middleware"
+	"server/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupProtectedRoutes(router *gin.Engine) {
-	router.Use(middleware.AuthMiddleware())
+func SetupProtectedRoutes(router *gin.Engine, app *controllers.App) {
+	router.Use(middleware.AuthMiddleware(app.Tokens))
+
+	auditMovie := func(action string) gin.HandlerFunc {
+		return app.Audit(action, models.ContentTypeMovie)
+	}
+	auditTVShow := func(action string) gin.HandlerFunc {
+		return app.Audit(action, models.ContentTypeTVShow)
+	}
 
 	// Movies
-	router.GET("/movies", controllers.GetMovies())
-	router.GET("/movie/:imdb_id", controllers.GetMovie())
-	router.POST("/add_movie", controllers.AddMovie())
-	router.PUT("/update_movie/:imdb_id", controllers.UpdateMovie())
-	router.DELETE("/delete_movie/:imdb_id", controllers.DeleteMovie())
-	router.GET("/recommended_movies", controllers.GetRecommendedMovies())
-	router.PATCH("/update_review/:imdb_id", controllers.AdminReviewUpdate())
+	router.GET("/movies", app.GetMovies())
+	router.GET("/movie/:imdb_id", app.GetMovie())
+	router.GET("/movie/:imdb_id/similar", app.GetSimilarMovies())
+	router.POST("/add_movie", auditMovie(models.AuditCreate), app.AddMovie())
+	router.PUT("/update_movie/:imdb_id", auditMovie(models.AuditUpdate), app.UpdateMovie())
+	router.PATCH("/movie/:imdb_id", auditMovie(models.AuditPatch), app.PatchMovie())
+	router.DELETE("/delete_movie/:imdb_id", auditMovie(models.AuditDelete), app.DeleteMovie())
+	router.GET("/recommended_movies", app.GetRecommendedMovies())
+	router.PATCH("/update_review/:imdb_id", auditMovie(models.AuditReview), app.AdminReviewUpdate())
+	router.GET("/movie/:imdb_id/revisions", app.GetMovieRevisions())
+	router.GET("/movie/:imdb_id/revisions/:revision_id", app.GetMovieRevision())
+	router.POST("/movie/:imdb_id/revisions/:revision_id/rollback", auditMovie(models.AuditRollback), app.RollbackMovie())
+	router.POST("/movie/:imdb_id/restore", auditMovie(models.AuditRestore), app.RestoreMovie())
 
 	// TV Shows
-	router.GET("/tv_shows", controllers.GetTVShows())
-	router.GET("/tv_shows/:imdb_id", controllers.GetTVShow())
-	router.GET("/tv_show/:imdb_id/season/:season_number", controllers.GetTVShowSeason())
-	router.POST("/add_tv_show", controllers.AddTVShow())
-	router.PUT("/update_tv_show/:imdb_id", controllers.UpdateTVShow())
-	router.POST("/tv_show/:imdb_id/add_season", controllers.AddSeason())
-	router.DELETE("/delete_tv_show/:imdb_id", controllers.DeleteTVShow())
-	router.PATCH("/update_tv_show_review/:imdb_id", controllers.AdminTVShowReviewUpdate())
-	router.GET("/recommended_tv_shows", controllers.GetRecommendedTVShows())
+	router.GET("/tv_shows", app.GetTVShows())
+	router.GET("/tv_shows/:imdb_id", app.GetTVShow())
+	router.GET("/tv_show/:imdb_id/season/:season_number", app.GetTVShowSeason())
+	router.GET("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", app.GetEpisode())
+	router.GET("/episodes/upcoming", app.GetUpcomingEpisodes())
+	router.GET("/tv_show/:imdb_id/similar", app.GetSimilarTVShows())
+	router.POST("/add_tv_show", auditTVShow(models.AuditCreate), app.AddTVShow())
+	router.PUT("/update_tv_show/:imdb_id", auditTVShow(models.AuditUpdate), app.UpdateTVShow())
+	router.PATCH("/tv_show/:imdb_id", auditTVShow(models.AuditPatch), app.PatchTVShow())
+	router.POST("/tv_show/:imdb_id/add_season", auditTVShow(models.AuditAddSeason), app.AddSeason())
+	router.PUT("/tv_show/:imdb_id/season/:season_number", auditTVShow(models.AuditUpdateSeason), app.UpdateSeason())
+	router.DELETE("/tv_show/:imdb_id/season/:season_number", auditTVShow(models.AuditDeleteSeason), app.DeleteSeason())
+	router.POST("/tv_show/:imdb_id/season/:season_number/add_episode", auditTVShow(models.AuditAddEpisode), app.AddEpisode())
+	router.PUT("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", auditTVShow(models.AuditUpdateEpisode), app.UpdateEpisode())
+	router.DELETE("/tv_show/:imdb_id/season/:season_number/episode/:episode_number", auditTVShow(models.AuditDeleteEpisode), app.DeleteEpisode())
+	router.DELETE("/delete_tv_show/:imdb_id", auditTVShow(models.AuditDelete), app.DeleteTVShow())
+	router.PATCH("/update_tv_show_review/:imdb_id", auditTVShow(models.AuditReview), app.AdminTVShowReviewUpdate())
+	router.GET("/tv_show/:imdb_id/revisions", app.GetTVShowRevisions())
+	router.GET("/tv_show/:imdb_id/revisions/:revision_id", app.GetTVShowRevision())
+	router.POST("/tv_show/:imdb_id/revisions/:revision_id/rollback", auditTVShow(models.AuditRollback), app.RollbackTVShow())
+	router.POST("/tv_show/:imdb_id/restore", auditTVShow(models.AuditRestore), app.RestoreTVShow())
+	router.GET("/recommended_tv_shows", app.GetRecommendedTVShows())
+
+	// Import & Backup
+	router.POST("/import/movies", auditMovie(models.AuditImport), app.ImportMovies())
+	router.POST("/import/tv_shows", auditTVShow(models.AuditImport), app.ImportTVShows())
+	router.GET("/catalog/export", app.Audit(models.AuditExport, ""), app.ExportCatalog())
+	router.POST("/catalog/restore", app.Audit(models.AuditRestoreAll, ""), app.RestoreCatalog())
+
+	// Trash
+	router.GET("/trash/movies", app.GetMovieTrash())
+	router.GET("/trash/tv_shows", app.GetTVShowTrash())
+
+	// Recommendations
+	router.GET("/recommendations", app.GetRecommendations())
+	router.GET("/onboarding", app.GetOnboardingSample())
+	router.POST("/onboarding", app.CompleteOnboarding())
+
+	// Charts
+	router.GET("/charts/trending", app.GetTrendingChart())
+
+	// Watchlist & Ratings
+	router.GET("/watchlist", app.GetWatchlist())
+	router.POST("/watchlist", app.AddToWatchlist())
+	router.DELETE("/watchlist/:imdb_id", app.RemoveFromWatchlist())
+	router.GET("/ratings", app.GetUserRatings())
+	router.PUT("/rating/:imdb_id", app.RateTitle())
+
+	// Users & Audit
+	router.PATCH("/user/:user_id/role", app.Audit(models.AuditRoleChange, ""), app.UpdateUserRole())
+	router.GET("/audit", app.GetAuditLog())
 }
@@ -2,11 +2,19 @@ package routes
 
 import (
 	"server/controllers"
+	"server/metrics"
+	"server/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupUnprotectedRoutes(router *gin.Engine) {
-	router.POST("/register", controllers.RegisterUser())
-	router.POST("/login", controllers.LoginUser())
+func SetupUnprotectedRoutes(router *gin.Engine, app *controllers.App) {
+	router.POST("/register", app.RegisterUser())
+	router.POST("/login", app.Audit(models.AuditLogin, ""), app.LoginUser())
+
+	// Probes
+	router.GET("/healthz", app.HealthCheck())
+	router.GET("/readyz", app.ReadinessCheck())
+	router.GET("/version", app.GetVersion())
+	router.GET("/metrics", metrics.Handler())
 }
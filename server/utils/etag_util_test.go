@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := ETag(3)
+	assert.Equal(t, `"3"`, etag)
+
+	tests := []struct {
+		header string
+		weak   bool
+		want   bool
+	}{
+		{`"3"`, false, true},
+		{`"2"`, false, false},
+		{`"1", "3"`, false, true},
+		{`*`, false, true},
+		{`W/"3"`, false, false},
+		{`W/"3"`, true, true},
+		{`"2", W/"3"`, true, true},
+		{``, true, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ETagMatches(tt.header, etag, tt.weak), "header %s, weak %v", tt.header, tt.weak)
+	}
+}
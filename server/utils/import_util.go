@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"server/models"
+)
+
+// Formats DecodeRecords reads.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// ErrUnknownFormat is returned for a format other than csv and ndjson.
+var ErrUnknownFormat = errors.New("format must be csv or ndjson")
+
+// DecodeRecords reads records of type T from r and calls each with the line
+// every record starts on and the record, or the error decoding that record.
+// NDJSON holds one JSON object per line, blank lines are skipped. CSV starts
+// with a header naming the JSON fields of T; string fields are taken as is
+// and any other value, numbers, objects and arrays alike, as JSON. Empty
+// cells are left out. The error returned is about the input as a whole, as
+// a CSV header naming an unknown field.
+func DecodeRecords[T any](r io.Reader, format string, each func(line int, record T, err error)) error {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r, each)
+	case FormatNDJSON:
+		return decodeNDJSON(r, each)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+func decodeNDJSON[T any](r io.Reader, each func(line int, record T, err error)) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if data = bytes.TrimSpace(data); len(data) > 0 {
+			var record T
+			decodeErr := json.Unmarshal(data, &record)
+			each(line, record, decodeErr)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+	}
+}
+
+func decodeCSV[T any](r io.Reader, each func(line int, record T, err error)) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := jsonFields(reflect.TypeFor[T]())
+	for _, column := range header {
+		if _, ok := fields[column]; !ok {
+			return fmt.Errorf("unknown column %q", column)
+		}
+	}
+
+	for {
+		cells, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var record T
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount):
+			each(parseErr.StartLine, record, err)
+		case err != nil:
+			return err
+		default:
+			line, _ := reader.FieldPos(0)
+			each(line, record, decodeCSVRecord(header, cells, fields, &record))
+		}
+	}
+}
+
+// Kinds of CSV cells, after the type of the field they fill.
+const (
+	cellJSON = iota
+	cellString
+	cellDate
+)
+
+// decodeCSVRecord turns the cells into a JSON object and decodes it.
+func decodeCSVRecord(header, cells []string, fields map[string]int, record any) error {
+	object := make(map[string]json.RawMessage, len(cells))
+	for i, cell := range cells {
+		column := header[i]
+		switch {
+		case cell == "":
+			continue
+		case fields[column] == cellString:
+			object[column], _ = json.Marshal(cell)
+		case fields[column] == cellDate:
+			date, err := models.ParseDate(cell)
+			if err != nil {
+				return fmt.Errorf("column %q %w", column, err)
+			}
+			object[column], _ = json.Marshal(date)
+		case json.Valid([]byte(cell)):
+			object[column] = json.RawMessage(cell)
+		default:
+			return fmt.Errorf("column %q is not valid JSON", column)
+		}
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, record)
+}
+
+// jsonFields maps the JSON names of the fields of a struct type, embedded
+// structs included, to the kind of cell filling them.
+func jsonFields(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embedded, kind := range jsonFields(field.Type) {
+				fields[embedded] = kind
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		switch {
+		case field.Type.Kind() == reflect.String:
+			fields[name] = cellString
+		case isDateType(field.Type):
+			fields[name] = cellDate
+		default:
+			fields[name] = cellJSON
+		}
+	}
+	return fields
+}
+
+// isDateType reports whether t is time.Time or a struct embedding it alone,
+// as the dates of the models do.
+func isDateType(t reflect.Type) bool {
+	timeType := reflect.TypeFor[time.Time]()
+	if t == timeType {
+		return true
+	}
+	return t.Kind() == reflect.Struct && t.NumField() == 1 &&
+		t.Field(0).Anonymous && t.Field(0).Type == timeType
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"server/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type importRecord struct {
+	ID    string   `json:"id"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+type decodedRecord struct {
+	line   int
+	record importRecord
+	err    error
+}
+
+func decodeAll(t *testing.T, input, format string) ([]decodedRecord, error) {
+	t.Helper()
+
+	var records []decodedRecord
+	err := DecodeRecords(strings.NewReader(input), format, func(line int, record importRecord, err error) {
+		records = append(records, decodedRecord{line, record, err})
+	})
+	return records, err
+}
+
+func TestDecodeRecords_CSV(t *testing.T) {
+	input := "id,count,tags\n" +
+		"1917,3,\"[\"\"war\"\"]\"\n" +
+		"b,x,\n" +
+		"c,1\n" +
+		"d,,\n"
+
+	records, err := decodeAll(t, input, FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+	assert.Equal(t, decodedRecord{2, importRecord{ID: "1917", Count: 3, Tags: []string{"war"}}, nil}, records[0])
+	assert.Equal(t, 3, records[1].line)
+	assert.ErrorContains(t, records[1].err, `column "count" is not valid JSON`)
+	assert.Equal(t, 4, records[2].line)
+	assert.Error(t, records[2].err)
+	assert.Equal(t, decodedRecord{5, importRecord{ID: "d"}, nil}, records[3])
+}
+
+func TestDecodeRecords_CSVDates(t *testing.T) {
+	// Aired embeds the time as the dates of the models do
+	type datedRecord struct {
+		ID       string              `json:"id"`
+		Released time.Time           `json:"released"`
+		Aired    struct{ time.Time } `json:"aired"`
+	}
+	input := "id,released,aired\n" +
+		"a,2024-01-31,2024-02-01\n" +
+		"b,2024-01-31T20:00:00Z,\n" +
+		"c,31/01/2024,\n"
+
+	var records []datedRecord
+	var errs []error
+	err := DecodeRecords(strings.NewReader(input), FormatCSV, func(_ int, record datedRecord, err error) {
+		records = append(records, record)
+		errs = append(errs, err)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), records[0].Released)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), records[0].Aired.Time)
+	assert.Equal(t, time.Date(2024, 1, 31, 20, 0, 0, 0, time.UTC), records[1].Released)
+	assert.NoError(t, errs[1])
+	assert.ErrorIs(t, errs[2], models.ErrInvalidDate)
+}
+
+func TestDecodeRecords_CSVUnknownColumn(t *testing.T) {
+	_, err := decodeAll(t, "id,name\n1,a\n", FormatCSV)
+	assert.ErrorContains(t, err, `unknown column "name"`)
+}
+
+func TestDecodeRecords_NDJSON(t *testing.T) {
+	input := `{"id": "a", "count": 1}` + "\n\n" +
+		`{"id": "b", "count": "one"}` + "\n" +
+		`{"id": "c", "tags": ["x"]}`
+
+	records, err := decodeAll(t, input, FormatNDJSON)
+
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, decodedRecord{1, importRecord{ID: "a", Count: 1}, nil}, records[0])
+	assert.Equal(t, 3, records[1].line)
+	assert.Error(t, records[1].err)
+	assert.Equal(t, decodedRecord{4, importRecord{ID: "c", Tags: []string{"x"}}, nil}, records[2])
+}
+
+func TestDecodeRecords_UnknownFormat(t *testing.T) {
+	_, err := decodeAll(t, "", "xml")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
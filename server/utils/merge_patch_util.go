@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrPatchNotObject is returned when a merge patch is not a JSON object.
+var ErrPatchNotObject = errors.New("merge patch must be a JSON object")
+
+// ParseMergePatch decodes a JSON merge patch (RFC 7396), which must be an
+// object to patch a document.
+func ParseMergePatch(data []byte) (map[string]any, error) {
+	var patch any
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+
+	object, ok := patch.(map[string]any)
+	if !ok {
+		return nil, ErrPatchNotObject
+	}
+	return object, nil
+}
+
+// MergePatch applies the merge patch to the JSON document doc as RFC 7396
+// describes: null removes a member, objects are merged recursively and any
+// other value, arrays included, replaces the member.
+func MergePatch(doc []byte, patch map[string]any) ([]byte, error) {
+	var target map[string]any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeObject(target, patch))
+}
+
+func mergeObject(target, patch map[string]any) map[string]any {
+	if target == nil {
+		target = map[string]any{}
+	}
+
+	for key, value := range patch {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+
+		if patchObject, ok := value.(map[string]any); ok {
+			targetObject, _ := target[key].(map[string]any)
+			target[key] = mergeObject(targetObject, patchObject)
+			continue
+		}
+		target[key] = value
+	}
+
+	return target
+}
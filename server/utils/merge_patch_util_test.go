@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The examples of RFC 7396, appendix A, that patch an object.
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		patch, err := ParseMergePatch([]byte(test.patch))
+		assert.NoError(t, err)
+
+		merged, err := MergePatch([]byte(test.doc), patch)
+		assert.NoError(t, err)
+		assert.JSONEq(t, test.want, string(merged), "patching %s with %s", test.doc, test.patch)
+	}
+}
+
+func TestParseMergePatch_NotObject(t *testing.T) {
+	_, err := ParseMergePatch([]byte(`["a"]`))
+	assert.ErrorIs(t, err, ErrPatchNotObject)
+}
@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// RespondError aborts the request with err, which the error middleware turns
+// into the response. err should be an *apierror.Error; any other error is
+// answered as an internal error.
+func RespondError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
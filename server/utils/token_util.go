@@ -1,18 +1,12 @@
 package utils
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"os"
 	"time"
 
-	"server/database"
-
 	"github.com/gin-gonic/gin"
 	jwt "github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/v2/bson"
-	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 type SignedDetails struct {
@@ -24,18 +18,24 @@ type SignedDetails struct {
 	jwt.RegisteredClaims
 }
 
-var SecretKey string = os.Getenv("SECRET_KEY")
-var SecretRefreshKey string = os.Getenv("SECRET_REFRESH_KEY")
-var userCollection *mongo.Collection = database.OpenCollection("users")
+// TokenService signs and validates the access and refresh tokens.
+type TokenService struct {
+	secretKey        string
+	secretRefreshKey string
+}
+
+func NewTokenService(secretKey, secretRefreshKey string) *TokenService {
+	return &TokenService{secretKey: secretKey, secretRefreshKey: secretRefreshKey}
+}
 
-func GenerateAllTokens(email, firstName, lastName, role, userId string) (string, string, error) {
-	signedToken, err := generateToken(email, firstName, lastName, role, userId, SecretKey,
+func (s *TokenService) GenerateAllTokens(email, firstName, lastName, role, userId string) (string, string, error) {
+	signedToken, err := generateToken(email, firstName, lastName, role, userId, s.secretKey,
 		24*time.Hour)
 	if err != nil {
 		return "", "", err // returns an empty token, an empty refresh token and an error
 	}
 
-	signedRefreshToken, err := generateToken(email, firstName, lastName, role, userId, SecretRefreshKey,
+	signedRefreshToken, err := generateToken(email, firstName, lastName, role, userId, s.secretRefreshKey,
 		168*time.Hour)
 	if err != nil {
 		return "", "", err // returns an empty token, an empty refresh token and an error
@@ -44,28 +44,6 @@ func GenerateAllTokens(email, firstName, lastName, role, userId string) (string,
 	return signedToken, signedRefreshToken, nil
 }
 
-func UpdateAllTokens(userId, token, refreshToken string) (err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
-
-	updateAt, _ := time.Parse(time.RFC3339, time.Now().Format(time.RFC3339))
-
-	updateData := bson.M{
-		"$set": bson.M{
-			"token":         token,
-			"refresh_token": refreshToken,
-			"update_at":     updateAt,
-		},
-	}
-
-	_, err = userCollection.UpdateOne(ctx, bson.M{"user_id": userId}, updateData)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func GetAccessToken(c *gin.Context) (string, error) {
 	authHeader := c.Request.Header.Get("Authorization")
 	if authHeader == "" {
@@ -80,11 +58,11 @@ func GetAccessToken(c *gin.Context) (string, error) {
 	return tokenString, nil
 }
 
-func ValidateToken(tokenString string) (*SignedDetails, error) {
+func (s *TokenService) ValidateToken(tokenString string) (*SignedDetails, error) {
 	claims := &SignedDetails{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(SecretKey), nil
+		return []byte(s.secretKey), nil
 	})
 	if err != nil {
 		return nil, err